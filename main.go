@@ -4,10 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 	"time"
+
 	"github.com/bitrise-io/go-steputils/v2/stepconf"
 	"github.com/bitrise-io/go-utils/v2/command"
 	"github.com/bitrise-io/go-utils/v2/env"
@@ -21,21 +20,73 @@ func main() {
 	os.Exit(run())
 }
 
-func run() int {
+func run() (exitCode int) {
+	start := time.Now()
 	logger := log.NewLogger()
 	archiver := createXcodebuildArchiver(logger)
+	timer := step.NewTimer()
+
+	stopTimer := timer.Start("process_inputs")
 	config, err := archiver.ProcessInputs()
+	stopTimer()
 	if err != nil {
 		logger.Errorf(formattedError(fmt.Errorf("Failed to process Step inputs: %w", err)))
 		return 1
 	}
 
+	if config.PrintProjectInfo {
+		err := archiver.PrintProjectInfo(step.PrintProjectInfoOpts{
+			ProjectPath:    config.ProjectPath,
+			Scheme:         config.Scheme,
+			Configuration:  config.Configuration,
+			XcodebuildPath: config.XcodebuildPath,
+		})
+		if err != nil {
+			logger.Errorf(formattedError(fmt.Errorf("Failed to print project info: %w", err)))
+			return 1
+		}
+		logger.Println()
+		logger.Infof("Print project info (print_project_info) is set, skipping the archive")
+		return 0
+	}
+
+	var result step.RunResult
+	var attempts int
+	var runErr error
+	defer func() {
+		archiver.CleanupTempWorkDir(step.CleanupTempWorkDirOpts{
+			TempWorkDir: config.TempWorkDir,
+			Cleanup:     config.CleanupTempDir,
+			Preserve:    config.KeepFailedArchive && runErr != nil,
+		})
+	}()
+
+	if config.NotifyWebhookURL != "" {
+		defer func() {
+			err := archiver.Notify(step.NotifyOpts{
+				WebhookURL:   string(config.NotifyWebhookURL),
+				Format:       config.NotifyFormat,
+				Scheme:       config.Scheme,
+				ArtifactName: result.ArtifactName,
+				Succeeded:    exitCode == 0,
+				Attempts:     attempts,
+				Duration:     time.Since(start),
+			})
+			if err != nil {
+				logger.Warnf("Failed to send notification: %s", err)
+			}
+		}()
+	}
+
 	dependenciesOpts := step.EnsureDependenciesOpts{
 		XCPretty: config.LogFormatter == "xcpretty",
 	}
-	if err := archiver.EnsureDependencies(dependenciesOpts); err != nil {
+	stopTimer = timer.Start("ensure_dependencies")
+	err = archiver.EnsureDependencies(dependenciesOpts)
+	stopTimer()
+	if err != nil {
 		var xcprettyInstallErr step.XCPrettyInstallError
-		if errors.As(err, &xcprettyInstallErr) {
+		if errors.As(err, &xcprettyInstallErr) && !config.FailOnLogFormatterError {
 			logger.Warnf("Installing xcpretty failed: %s", err)
 			logger.Warnf("Switching to xcodebuild for log formatter")
 			config.LogFormatter = "xcodebuild"
@@ -45,98 +96,204 @@ func run() int {
 		}
 	}
 
-	maxRetries := config.MaxRetryCount
-	if maxRetries < 1 {
-		maxRetries = 1
+	if config.ResolvePackageDependencies {
+		stopTimer = timer.Start("resolve_package_dependencies")
+		err = archiver.ResolvePackageDependencies(step.ResolvePackageDependenciesOpts{
+			ProjectPath:                 config.ProjectPath,
+			Scheme:                      config.Scheme,
+			ClonedSourcePackagesDirPath: config.ClonedSourcePackagesDirPath,
+			MaxAttempts:                 config.ResolvePackageDependenciesRetryCount,
+			OutputDir:                   config.OutputDir,
+			OverwriteOutputs:            config.OverwriteOutputs,
+			XcodebuildPath:              config.XcodebuildPath,
+		})
+		stopTimer()
+		if err != nil {
+			logger.Errorf(formattedError(fmt.Errorf("Failed to resolve Swift package dependencies: %w", err)))
+			return 1
+		}
 	}
-	
-	var result step.RunResult
-	var runErr error
 
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		if attempt > 1 {
-			logger.Infof("Archive attempt %d of %d", attempt, maxRetries)
-			// Perform explicit clean and disable cache
-			cleanArgs := []string{"clean"}
-			if strings.HasSuffix(config.ProjectPath, ".xcworkspace") {
-				cleanArgs = append(cleanArgs, "-workspace", config.ProjectPath)
-			} else {
-				cleanArgs = append(cleanArgs, "-project", config.ProjectPath)
-			}
-			cleanArgs = append(cleanArgs, "-scheme", config.Scheme)
-			
-			cleanCmd := exec.Command("xcodebuild", cleanArgs...)
-			logger.Infof("Performing clean: %s", cleanCmd.String())
-			if output, err := cleanCmd.CombinedOutput(); err != nil {
-				logger.Warnf("Failed to clean project: %s", err)
-				logger.Warnf("Clean command output: %s", string(output))
-			}
-			// Clear Xcode caches and derived data
-			cmd := exec.Command("rm", "-rf", filepath.Join(os.Getenv("HOME"), "Library/Caches/com.apple.dt.Xcode"))
-			logger.Infof("Cleaning xcode cache: %s", cmd.String())
-			if output, err := cmd.CombinedOutput(); err != nil {
-				logger.Warnf("Failed to clear Xcode caches: %s", err)
-				logger.Warnf("Xcode cache command output: %s", string(output))
-			}			
-			cmd = exec.Command("rm", "-rf", filepath.Join(os.Getenv("HOME"), "Library/Caches/org.swift.swiftpm"))
-			logger.Infof("Cleaning Swift Package Manager cache: %s", cmd.String())
-			if output, err := cmd.CombinedOutput(); err != nil {
-				logger.Warnf("Failed to clear Swift Package Manager cache: %s", err)
-				logger.Warnf("Swift Package Manager cache command output: %s", string(output))
-			}			
-			cmd = exec.Command("rm", "-rf", filepath.Join(os.Getenv("HOME"), "Library/Developer/Xcode/DerivedData/*"))
-			logger.Infof("Cleaning derived data: %s", cmd.String())
-			if output, err := cmd.CombinedOutput(); err != nil {
-				logger.Warnf("Failed to clear derived data: %s", err)
-				logger.Warnf("Derived data command output: %s", string(output))
-			}
-			// Clear build state cache
-			cmd = exec.Command("rm", "-rf", filepath.Join(os.Getenv("HOME"), "Library/Developer/Xcode/BuildState/*"))
-			logger.Infof("Cleaning build state cache: %s", cmd.String())
-			if output, err := cmd.CombinedOutput(); err != nil {
-				logger.Warnf("Failed to clear build state cache: %s", err)
-				logger.Warnf("Build state cache command output: %s", string(output))
-			}
+	var retryResult step.ArchiveWithRetryResult
+	retryResult, runErr = archiver.ArchiveWithRetry(createArchiveWithRetryOptions(config, timer, "archive"))
+	result, attempts = retryResult.RunResult, retryResult.Attempts
+	attemptLogPaths := retryResult.AttemptLogPaths
 
-			// Generate project using tuist
-			tuistCmd := exec.Command("tuist", "generate", "--configuration", config.Configuration, "-p", "tuist")
-			logger.Infof("Generating project with tuist: %s", tuistCmd.String())
-			if output, err := tuistCmd.CombinedOutput(); err != nil {
-				logger.Warnf("Failed to generate project with tuist: %s", err)
-				logger.Warnf("Tuist command output: %s", string(output))
+	if runErr == nil && result.AdditionalExportErr != nil {
+		logger.Errorf(formattedError(fmt.Errorf("Failed to export the additional distribution methods: %w", result.AdditionalExportErr)))
+		exitCode = 1
+	}
+
+	if runErr == nil && exitCode == 0 {
+		for _, archive := range config.ConfigurationArchives {
+			exports, logPaths, err := archiveConfiguration(archiver, config, archive, result.ArtifactName, timer, logger)
+			attemptLogPaths = append(attemptLogPaths, logPaths...)
+			result.AdditionalIPAExports = append(result.AdditionalIPAExports, exports...)
+			if err != nil {
+				if config.FailOnAdditionalExportError {
+					logger.Errorf(formattedError(err))
+					exitCode = 1
+					break
+				}
+				logger.Warnf("%s, continuing with the remaining configurations", err)
 			}
+		}
+	}
 
-			config.CacheLevel = "none"
-			time.Sleep(30 * time.Second)
+	if runErr != nil {
+		logger.Errorf(formattedError(fmt.Errorf("Failed to execute Step main logic after %d attempts: %w", attempts, runErr)))
+		exitCode = 1
+		// don't return as step outputs needs to be exported even in case of failure (for example the xcodebuild logs)
+	}
+
+	exportOpts := createExportOptions(config, result)
+	exportOpts.XcodebuildAttemptLogPaths = attemptLogPaths
+	if runErr == nil {
+		exportOpts.SucceededAttempt = attempts
+	}
+	stopTimer = timer.Start("export_output")
+	exportResult, exportErr := archiver.ExportOutput(exportOpts)
+	stopTimer()
+
+	if exportErr == nil && config.FirebaseAppID != "" && exportResult.DSYMDir != "" {
+		stopTimer = timer.Start("upload_crashlytics_symbols")
+		err := archiver.UploadCrashlyticsSymbols(step.UploadCrashlyticsSymbolsOpts{
+			FirebaseAppID:               config.FirebaseAppID,
+			UploadSymbolsPath:           config.FirebaseUploadSymbolsPath,
+			DSYMDir:                     exportResult.DSYMDir,
+			Platform:                    result.Platform,
+			ProjectPath:                 config.ProjectPath,
+			ClonedSourcePackagesDirPath: config.ClonedSourcePackagesDirPath,
+		})
+		stopTimer()
+		if err != nil {
+			if config.FailOnSymbolUploadError {
+				logger.Errorf(formattedError(fmt.Errorf("Failed to upload dSYMs to Firebase Crashlytics: %w", err)))
+				exitCode = 1
+			} else {
+				logger.Warnf("Failed to upload dSYMs to Firebase Crashlytics: %s", err)
+			}
 		}
+	}
 
-		runOpts := createRunOptions(config)
-		result, runErr = archiver.Run(runOpts)
-		if runErr == nil {
-			break
+	if runErr == nil && exportErr == nil {
+		if err := step.CheckWarningBudget(exportResult.Warnings, config.MaxAllowedWarnings); err != nil {
+			logger.Errorf(formattedError(fmt.Errorf("Failed to check build warnings: %w", err)))
+			exitCode = 1
 		}
+	}
 
-		if attempt < maxRetries {
-			logger.Warnf("Archive failed, will retry: %s", runErr)
+	var ipaVerification *step.IPAVerification
+	if config.VerifyExportedIPA && exportErr == nil && exportResult.IPAPath != "" {
+		stopTimer = timer.Start("verify_ipa")
+		verification, err := archiver.VerifyIPA(step.VerifyIPAOpts{
+			IPAPath:                  exportResult.IPAPath,
+			ExpectedBundleIdentifier: config.BundleIdentifier,
+			TempWorkDir:              config.TempWorkDir,
+		})
+		stopTimer()
+		ipaVerification = &verification
+		if err != nil {
+			logger.Errorf(formattedError(fmt.Errorf("Failed to verify the exported IPA: %w", err)))
+			exitCode = 1
 		}
 	}
 
-	exitCode := 0
-	if runErr != nil {
-		logger.Errorf(formattedError(fmt.Errorf("Failed to execute Step main logic after %d attempts: %w", maxRetries, runErr)))
-		exitCode = 1
-		// don't return as step outputs needs to be exported even in case of failure (for example the xcodebuild logs)
+	var entitlements *step.EntitlementsSummary
+	if exportErr == nil && exportResult.IPAPath != "" {
+		entitlements = archiver.ExportEntitlements(step.ExportEntitlementsOpts{
+			OutputDir:        config.OutputDir,
+			IPAPath:          exportResult.IPAPath,
+			OverwriteOutputs: config.OverwriteOutputs,
+		})
+		archiver.ExportEmbeddedFrameworks(step.ExportEmbeddedFrameworksOpts{
+			OutputDir:        config.OutputDir,
+			IPAPath:          exportResult.IPAPath,
+			OverwriteOutputs: config.OverwriteOutputs,
+		})
 	}
 
-	exportOpts := createExportOptions(config, result)
-	if err := archiver.ExportOutput(exportOpts); err != nil {
-		logger.Errorf(formattedError(fmt.Errorf("Failed to export Step outputs: %w", err)))
+	timer.PrintPhases(logger)
+
+	summary := step.BuildSummary{
+		Phases:          timer.Phases(),
+		FreeDiskSpaceMB: result.FreeDiskSpaceMB,
+		Entitlements:    entitlements,
+		AppVersion:      exportResult.AppVersion,
+		ThinnedVariants: exportResult.ThinnedVariants,
+		Attempts:        attempts,
+		IPAVerification: ipaVerification,
+		Flaky:           runErr == nil && attempts > 1,
+	}
+	if config.GitInfo != (step.GitInfo{}) {
+		summary.Git = &config.GitInfo
+	}
+	if err := archiver.ExportBuildSummary(config.OutputDir, config.OverwriteOutputs, summary); err != nil {
+		logger.Warnf("Failed to export build summary: %s", err)
+	}
+
+	if exportErr != nil {
+		logger.Errorf(formattedError(fmt.Errorf("Failed to export Step outputs: %w", exportErr)))
 		return 1
 	}
 
 	return exitCode
 }
 
+// createArchiveWithRetryOptions returns the archive options of the phase, retried according to the retry inputs.
+func createArchiveWithRetryOptions(config step.Config, timer *step.Timer, phase string) step.ArchiveWithRetryOpts {
+	return step.ArchiveWithRetryOpts{
+		RunOpts: createRunOptions(config),
+		Phase:   phase,
+		Timer:   timer,
+
+		MaxAttempts:         config.MaxRetryCount,
+		RetryPolicies:       config.ArchiveRetryPolicies,
+		CleanupPlan:         config.RetryCleanupPlan,
+		PreserveDerivedData: config.RetryPreservesDerivedData,
+		KeepFailedArchive:   config.KeepFailedArchive,
+	}
+}
+
+// archiveConfiguration archives the scheme with the configuration of a ConfigurationArchive, and exports its distribution methods.
+// The attempt logs and the exported IPAs are grouped under the configuration named subdirectory of the OutputDir.
+func archiveConfiguration(archiver step.XcodebuildArchiver, config step.Config, archive step.ConfigurationArchive, artifactName string, timer *step.Timer, logger log.Logger) ([]step.AdditionalIPAExport, []string, error) {
+	logger.Println()
+	logger.Infof("Archiving the %s configuration", archive.Configuration)
+
+	config.Configuration = archive.Configuration
+	config.ExportMethod = archive.ExportMethod
+	config.AdditionalExportMethodList = archive.AdditionalExportMethods
+	config.CodesignManager = archive.CodesignManager
+	config.AdditionalExportCodesignManagers = archive.AdditionalExportCodesignManagers
+	config.ArtifactName = artifactName
+	config.OutputDir = filepath.Join(config.OutputDir, archive.Configuration)
+	if err := os.MkdirAll(config.OutputDir, 0777); err != nil {
+		return nil, nil, fmt.Errorf("Failed to create the output dir of the %s configuration: %w", archive.Configuration, err)
+	}
+
+	retryResult, err := archiver.ArchiveWithRetry(createArchiveWithRetryOptions(config, timer, "archive_"+archive.Configuration))
+	result, attemptLogPaths := retryResult.RunResult, retryResult.AttemptLogPaths
+	if err != nil {
+		return nil, attemptLogPaths, fmt.Errorf("Failed to archive the %s configuration: %w", archive.Configuration, err)
+	}
+
+	exports := []step.AdditionalIPAExport{{
+		Method:            archive.ExportMethod,
+		IPAExportDir:      result.IPAExportDir,
+		ExportOptionsPath: result.ExportOptionsPath,
+		Configuration:     archive.Configuration,
+	}}
+	for _, export := range result.AdditionalIPAExports {
+		export.Configuration = archive.Configuration
+		exports = append(exports, export)
+	}
+	if result.AdditionalExportErr != nil {
+		return exports, attemptLogPaths, fmt.Errorf("Failed to export the additional distribution methods of the %s configuration: %w", archive.Configuration, result.AdditionalExportErr)
+	}
+	return exports, attemptLogPaths, nil
+}
+
 func createXcodebuildArchiver(logger log.Logger) step.XcodebuildArchiver {
 	xcodeVersionProvider := step.NewXcodebuildXcodeVersionProvider()
 	envRepository := env.NewRepository()
@@ -146,48 +303,100 @@ func createXcodebuildArchiver(logger log.Logger) step.XcodebuildArchiver {
 	pathModifier := pathutil.NewPathModifier()
 	fileManager := fileutil.NewFileManager()
 	cmdFactory := command.NewFactory(envRepository)
+	diskSpaceChecker := step.NewStatfsDiskSpaceChecker()
+	sleeper := step.NewTimeSleeper()
 
-	return step.NewXcodebuildArchiver(xcodeVersionProvider, inputParser, pathProvider, pathChecker, pathModifier, fileManager, logger, cmdFactory)
+	return step.NewXcodebuildArchiver(xcodeVersionProvider, inputParser, pathProvider, pathChecker, pathModifier, fileManager, logger, cmdFactory, diskSpaceChecker, sleeper)
 }
 
 func createRunOptions(config step.Config) step.RunOpts {
 	return step.RunOpts{
-		ProjectPath:       config.ProjectPath,
-		Scheme:            config.Scheme,
-		Configuration:     config.Configuration,
-		LogFormatter:      config.LogFormatter,
-		XcodeMajorVersion: config.XcodeMajorVersion,
-		ArtifactName:      config.ArtifactName,
+		ProjectPath:         config.ProjectPath,
+		Scheme:              config.Scheme,
+		Configuration:       config.Configuration,
+		LogFormatter:        config.LogFormatter,
+		XcodebuildVerbosity: config.XcodebuildVerbosity,
+		XcodeMajorVersion:   config.XcodeMajorVersion,
+		ArtifactName:        config.ArtifactName,
+		OutputDir:           config.OutputDir,
+		OverwriteOutputs:    config.OverwriteOutputs,
+		MinFreeDiskMB:       config.MinFreeDiskMB,
+		HeartbeatInterval:   time.Duration(config.HeartbeatSeconds) * time.Second,
+		HangThreshold:       hangThreshold(config),
+		XcodebuildPath:      config.XcodebuildPath,
+		BuildParallelism:    config.BuildParallelism,
+
+		SkipPackagePluginValidation: config.SkipPackagePluginValidation,
+		SkipMacroValidation:         config.SkipMacroValidation,
+		PreArchiveScript:            config.PreArchiveScript,
+		ProjectGenerated:            config.ProjectGenerated,
+		TempWorkDir:                 config.TempWorkDir,
 
-		CodesignManager: config.CodesignManager,
+		CodesignManager:                  config.CodesignManager,
+		AdditionalExportCodesignManagers: config.AdditionalExportCodesignManagers,
+		AllowProvisioningUpdates:         config.AllowProvisioningUpdates,
+		SkipCodesigning:                  config.SkipCodesigning,
+		BuildForSimulator:                config.BuildForSimulator,
+		KeychainPath:                     config.KeychainPath,
+		KeychainPassword:                 config.KeychainPassword,
+		CodesignFiles:                    config.CodesignFiles,
 
 		PerformCleanAction:          config.PerformCleanAction,
 		XcconfigContent:             config.XcconfigContent,
 		XcodebuildAdditionalOptions: config.XcodebuildAdditionalOptions,
+		Destination:                 config.Destination,
+		Platform:                    config.Platform,
+		SDK:                         config.SDK,
 		CacheLevel:                  config.CacheLevel,
+		XcodebuildEnvVars:           config.XcodebuildEnvVars,
+
+		ClonedSourcePackagesDirPath: config.ClonedSourcePackagesDirPath,
+		PackageDependenciesResolved: config.ResolvePackageDependencies,
 
 		CustomExportOptionsPlistContent: config.ExportOptionsPlistContent,
 		ExportMethod:                    config.ExportMethod,
+		AdditionalExportMethods:         config.AdditionalExportMethodList,
+		FailOnAdditionalExportError:     config.FailOnAdditionalExportError,
 		ICloudContainerEnvironment:      config.ICloudContainerEnvironment,
 		ExportDevelopmentTeam:           config.ExportDevelopmentTeam,
+		Thinning:                        config.Thinning,
+		OTAManifest:                     config.OTAManifest,
 		UploadBitcode:                   config.UploadBitcode,
 		CompileBitcode:                  config.CompileBitcode,
 	}
 }
 
+func hangThreshold(config step.Config) time.Duration {
+	if !config.CaptureHangDiagnostics {
+		return 0
+	}
+	return time.Duration(config.HangThresholdSeconds) * time.Second
+}
+
 func createExportOptions(config step.Config, result step.RunResult) step.ExportOpts {
 	return step.ExportOpts{
-		OutputDir:      config.OutputDir,
-		ArtifactName:   result.ArtifactName,
-		ExportAllDsyms: config.ExportAllDsyms,
+		OutputDir:        config.OutputDir,
+		ArtifactName:     result.ArtifactName,
+		Scheme:           config.Scheme,
+		ExportAllDsyms:   config.ExportAllDsyms,
+		ExportNestedApps: config.ExportNestedApps,
+		TempWorkDir:      config.TempWorkDir,
+		UploadBitcode:    config.UploadBitcode,
+		CompileBitcode:   config.CompileBitcode,
 
-		Archive: result.Archive,
+		Archive:             result.Archive,
+		UnsignedArchivePath: result.UnsignedArchivePath,
+		SimulatorAppPath:    result.SimulatorAppPath,
 
-		ExportOptionsPath: result.ExportOptionsPath,
-		IPAExportDir:      result.IPAExportDir,
+		ExportOptionsPath:    result.ExportOptionsPath,
+		IPAExportDir:         result.IPAExportDir,
+		AdditionalIPAExports: result.AdditionalIPAExports,
 
 		XcodebuildArchiveLog:       result.XcodebuildArchiveLog,
 		XcodebuildExportArchiveLog: result.XcodebuildExportArchiveLog,
 		IDEDistrubutionLogsDir:     result.IDEDistrubutionLogsDir,
+
+		OutputDirStrategy: config.OutputDirStrategy,
+		OverwriteOutputs:  config.OverwriteOutputs,
 	}
 }
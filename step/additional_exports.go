@@ -0,0 +1,156 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1command "github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/sliceutil"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/v2/codesign"
+)
+
+var exportMethods = []string{
+	string(exportoptions.MethodAppStore),
+	string(exportoptions.MethodAdHoc),
+	string(exportoptions.MethodEnterprise),
+	string(exportoptions.MethodDevelopment),
+}
+
+// AdditionalIPAExport is an IPA exported from the archive with an additional distribution method.
+type AdditionalIPAExport struct {
+	Method            string
+	IPAExportDir      string
+	ExportOptionsPath string
+	// Configuration is set if the IPA is exported from an additional archive of another configuration
+	Configuration string
+}
+
+// parseAdditionalExportMethods parses the comma or newline separated list of additional distribution methods.
+// The primary distribution method and the duplicates are left out.
+func parseAdditionalExportMethods(s, primaryMethod string) ([]string, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+
+	var methods []string
+	for _, field := range fields {
+		method := strings.TrimSpace(field)
+		if method == "" || method == primaryMethod || sliceutil.IsStringInSlice(method, methods) {
+			continue
+		}
+
+		if !sliceutil.IsStringInSlice(method, exportMethods) {
+			return nil, fmt.Errorf("unknown distribution method: %s", method)
+		}
+
+		methods = append(methods, method)
+	}
+	return methods, nil
+}
+
+// ipaPathEnvKeyForMethod returns the Environment Variable of an additional distribution method's IPA (eg. BITRISE_IPA_PATH_AD_HOC).
+func ipaPathEnvKeyForMethod(method string) string {
+	return bitriseIPAPthEnvKey + "_" + strings.ToUpper(strings.ReplaceAll(method, "-", "_"))
+}
+
+// createAdditionalExportCodesignManagers creates a code signing manager per additional distribution method,
+// as the code signing assets (eg. the provisioning profiles) depend on the distribution method.
+func (s XcodebuildArchiver) createAdditionalExportCodesignManagers(config Config, methods []string) (map[string]*codesign.Manager, error) {
+	managers := map[string]*codesign.Manager{}
+	for _, method := range methods {
+		methodConfig := config
+		methodConfig.ExportMethod = method
+		manager, err := s.createCodesignManager(methodConfig)
+		if err != nil {
+			return nil, fmt.Errorf("%s distribution method: %w", method, err)
+		}
+		managers[method] = &manager
+	}
+	return managers, nil
+}
+
+// exportAdditionalIPAs re-exports the archive once per additional distribution method, as the export is cheap compared to the archive.
+// The code signing assets of a method are prepared with its code signing manager (if any) before the export.
+// If failFast is not set, a failed export is logged and the remaining methods are still exported.
+func (s XcodebuildArchiver) exportAdditionalIPAs(opts xcodeIPAExportOpts, methods []string, codesignManagers map[string]*codesign.Manager, failFast bool) ([]AdditionalIPAExport, error) {
+	var exports []AdditionalIPAExport
+	for _, method := range methods {
+		s.logger.Println()
+		s.logger.Infof("Exporting %s IPA from the archive", method)
+
+		exportOut, err := s.exportAdditionalIPA(opts, method, codesignManagers[method])
+		if err != nil {
+			if failFast {
+				return exports, fmt.Errorf("failed to export %s IPA: %w", method, err)
+			}
+			s.logger.Warnf("Failed to export %s IPA, continuing with the remaining distribution methods: %s", method, err)
+			continue
+		}
+
+		exports = append(exports, AdditionalIPAExport{
+			Method:            method,
+			IPAExportDir:      exportOut.IPAExportDir,
+			ExportOptionsPath: exportOut.ExportOptionsPath,
+		})
+	}
+	return exports, nil
+}
+
+func (s XcodebuildArchiver) exportAdditionalIPA(opts xcodeIPAExportOpts, method string, codesignManager *codesign.Manager) (xcodeIPAExportResult, error) {
+	if codesignManager != nil {
+		s.logger.Printf("Preparing code signing assets (certificates, profiles) of the %s distribution method", method)
+		if _, err := codesignManager.PrepareCodesigning(); err != nil {
+			return xcodeIPAExportResult{}, fmt.Errorf("failed to manage code signing: %s", err)
+		}
+	}
+
+	opts.ExportMethod = method
+	exportOut, err := s.xcodeIPAExport(opts)
+	if err != nil {
+		s.printCodesignHints(exportOut.XcodebuildExportArchiveLog)
+	}
+	return exportOut, err
+}
+
+// exportAdditionalIPAOutputs exports the IPAs of the additional distribution methods into method named subdirectories of the OutputDir,
+// grouped under a configuration named subdirectory if exported from an additional archive of another configuration.
+func (s XcodebuildArchiver) exportAdditionalIPAOutputs(exports []AdditionalIPAExport, outputDir, artifactName string, outputPaths outputPathResolver) error {
+	for _, export := range exports {
+		ipaFiles, err := filepath.Glob(filepath.Join(export.IPAExportDir, "*.ipa"))
+		if err != nil {
+			return fmt.Errorf("failed to search for %s .ipa file, error: %s", export.Method, err)
+		}
+		if len(ipaFiles) == 0 {
+			return fmt.Errorf("no %s .ipa file found at export dir: %s", export.Method, export.IPAExportDir)
+		}
+
+		methodDir := filepath.Join(outputDir, export.Configuration, export.Method)
+		if err := os.MkdirAll(methodDir, 0777); err != nil {
+			return fmt.Errorf("failed to create %s output dir, error: %s", export.Method, err)
+		}
+
+		ipaPath, err := outputPaths.resolve(filepath.Join(methodDir, artifactName+".ipa"))
+		if err != nil {
+			return err
+		}
+		envKey := ipaPathEnvKeyForMethod(export.Method)
+		if err := ExportOutputFile(s.cmdFactory, ipaFiles[0], ipaPath, envKey); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", envKey, err)
+		}
+		s.logger.Donef("The %s ipa path is now available in the Environment Variable: %s (value: %s)", export.Method, envKey, ipaPath)
+
+		if export.ExportOptionsPath != "" {
+			exportOptionsPath, err := outputPaths.resolve(filepath.Join(methodDir, "export_options.plist"))
+			if err != nil {
+				return err
+			}
+			if err := v1command.CopyFile(export.ExportOptionsPath, exportOptionsPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package step
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseAdditionalExportMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		primary string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", input: "", primary: "app-store", want: nil},
+		{name: "comma separated", input: "ad-hoc, development", primary: "app-store", want: []string{"ad-hoc", "development"}},
+		{name: "primary and duplicates are left out", input: "app-store\nad-hoc\nad-hoc", primary: "app-store", want: []string{"ad-hoc"}},
+		{name: "unknown method", input: "ad-hoc,testflight", primary: "app-store", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAdditionalExportMethods(tt.input, tt.primary)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ipaPathEnvKeyForMethod(t *testing.T) {
+	require.Equal(t, "BITRISE_IPA_PATH_AD_HOC", ipaPathEnvKeyForMethod("ad-hoc"))
+	require.Equal(t, "BITRISE_IPA_PATH_APP_STORE", ipaPathEnvKeyForMethod("app-store"))
+}
+
+func TestXcodebuildArchiver_exportAdditionalIPAOutputs(t *testing.T) {
+	if _, err := exec.LookPath("rsync"); err != nil {
+		t.Skip("the outputs are copied with rsync")
+	}
+
+	outputDir := t.TempDir()
+	factory := &recordingCommandFactory{}
+	archiver := XcodebuildArchiver{cmdFactory: factory, logger: log.NewLogger()}
+	outputPaths := outputPathResolver{logger: log.NewLogger()}
+
+	var exports []AdditionalIPAExport
+	for _, method := range []string{"ad-hoc", "development"} {
+		exportDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(exportDir, "Sample.ipa"), []byte(method), 0644))
+		exportOptionsPath := filepath.Join(exportDir, "export_options.plist")
+		require.NoError(t, os.WriteFile(exportOptionsPath, []byte(method), 0644))
+		exports = append(exports, AdditionalIPAExport{Method: method, IPAExportDir: exportDir, ExportOptionsPath: exportOptionsPath})
+	}
+
+	require.NoError(t, archiver.exportAdditionalIPAOutputs(exports, outputDir, "MyApp", outputPaths))
+
+	for _, method := range []string{"ad-hoc", "development"} {
+		content, err := os.ReadFile(filepath.Join(outputDir, method, "MyApp.ipa"))
+		require.NoError(t, err)
+		require.Equal(t, method, string(content))
+		require.FileExists(t, filepath.Join(outputDir, method, "export_options.plist"))
+	}
+	require.Equal(t, []string{
+		"envman add --key BITRISE_IPA_PATH_AD_HOC",
+		"envman add --key BITRISE_IPA_PATH_DEVELOPMENT",
+	}, factory.commands)
+
+	missing := []AdditionalIPAExport{{Method: "enterprise", IPAExportDir: t.TempDir()}}
+	require.Error(t, archiver.exportAdditionalIPAOutputs(missing, outputDir, "MyApp", outputPaths))
+}
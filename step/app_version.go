@@ -0,0 +1,150 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"howett.net/plist"
+)
+
+const (
+	bitriseAppVersionEnvKey     = "BITRISE_APP_VERSION"
+	bitriseAppBuildNumberEnvKey = "BITRISE_APP_BUILD_NUMBER"
+)
+
+// AppVersion is the version and build number of the archived app, included in the build summary.
+type AppVersion struct {
+	Version     string `json:"version"`
+	BuildNumber string `json:"build_number"`
+}
+
+type bundleVersionInfo struct {
+	CFBundleShortVersionString string `plist:"CFBundleShortVersionString"`
+	CFBundleVersion            string `plist:"CFBundleVersion"`
+}
+
+type archiveInfo struct {
+	ApplicationProperties struct {
+		ApplicationPath            string `plist:"ApplicationPath"`
+		CFBundleShortVersionString string `plist:"CFBundleShortVersionString"`
+		CFBundleVersion            string `plist:"CFBundleVersion"`
+	} `plist:"ApplicationProperties"`
+}
+
+// exportAppVersion reads the version and build number of the archived app and exports them.
+// It is best-effort: failures are logged as warnings and nil is returned.
+func (s XcodebuildArchiver) exportAppVersion(archivePath, scheme string) *AppVersion {
+	version, err := readArchiveAppVersion(archivePath, scheme)
+	if err != nil {
+		s.logger.Warnf("Failed to read the app version from the archive: %s", err)
+		return nil
+	}
+
+	for _, env := range []struct{ key, value string }{
+		{bitriseAppVersionEnvKey, version.Version},
+		{bitriseAppBuildNumberEnvKey, version.BuildNumber},
+	} {
+		key, value := env.key, env.value
+		if err := exportEnvironmentWithEnvman(s.cmdFactory, key, value); err != nil {
+			s.logger.Warnf("Failed to export %s, error: %s", key, err)
+			return nil
+		}
+		s.logger.Donef("The app version is now available in the Environment Variable: %s (value: %s)", key, value)
+	}
+
+	return &version
+}
+
+// readArchiveAppVersion reads the CFBundleShortVersionString and CFBundleVersion of the archive's primary app.
+// Values which are not resolved build setting references (eg. `$(MARKETING_VERSION)`) fall back to the archive's application properties.
+func readArchiveAppVersion(archivePath, scheme string) (AppVersion, error) {
+	var archive archiveInfo
+	if err := readPlist(filepath.Join(archivePath, "Info.plist"), &archive); err != nil {
+		return AppVersion{}, fmt.Errorf("failed to read the archive's Info.plist: %w", err)
+	}
+
+	appPaths, err := filepath.Glob(filepath.Join(archivePath, "Products", "Applications", "*.app"))
+	if err != nil {
+		return AppVersion{}, fmt.Errorf("failed to search for the archived app: %w", err)
+	}
+
+	appPath, err := selectPrimaryApp(appPaths, filepath.Join(archivePath, "Products", archive.ApplicationProperties.ApplicationPath), scheme)
+	if err != nil {
+		return AppVersion{}, err
+	}
+
+	var app bundleVersionInfo
+	if err := readPlist(filepath.Join(appPath, "Info.plist"), &app); err != nil {
+		return AppVersion{}, fmt.Errorf("failed to read the app's Info.plist: %w", err)
+	}
+
+	version, err := resolvedBundleValue("CFBundleShortVersionString", app.CFBundleShortVersionString, archive.ApplicationProperties.CFBundleShortVersionString)
+	if err != nil {
+		return AppVersion{}, err
+	}
+	buildNumber, err := resolvedBundleValue("CFBundleVersion", app.CFBundleVersion, archive.ApplicationProperties.CFBundleVersion)
+	if err != nil {
+		return AppVersion{}, err
+	}
+
+	return AppVersion{Version: version, BuildNumber: buildNumber}, nil
+}
+
+// selectPrimaryApp returns the archive's primary app: the app referenced by the archive's Info.plist,
+// or the app named after the scheme, or the only app of the archive.
+func selectPrimaryApp(appPaths []string, applicationPath, scheme string) (string, error) {
+	sort.Strings(appPaths)
+
+	for _, appPath := range appPaths {
+		if appPath == applicationPath {
+			return appPath, nil
+		}
+	}
+	for _, appPath := range appPaths {
+		if strings.TrimSuffix(filepath.Base(appPath), ".app") == scheme {
+			return appPath, nil
+		}
+	}
+
+	switch len(appPaths) {
+	case 0:
+		return "", fmt.Errorf("no app found in the archive")
+	case 1:
+		return appPaths[0], nil
+	default:
+		var names []string
+		for _, appPath := range appPaths {
+			names = append(names, filepath.Base(appPath))
+		}
+		return "", fmt.Errorf("multiple apps found in the archive, none of them matches the scheme (%s): %s", scheme, strings.Join(names, ", "))
+	}
+}
+
+func resolvedBundleValue(key, value, fallback string) (string, error) {
+	if value != "" && !isUnresolvedBuildSetting(value) {
+		return value, nil
+	}
+	if fallback != "" && !isUnresolvedBuildSetting(fallback) {
+		return fallback, nil
+	}
+	if value == "" {
+		return "", fmt.Errorf("%s not found in the app's Info.plist", key)
+	}
+	return "", fmt.Errorf("%s of the app is not resolved: %s", key, value)
+}
+
+func isUnresolvedBuildSetting(value string) bool {
+	return strings.Contains(value, "$(") || strings.Contains(value, "${")
+}
+
+func readPlist(pth string, v interface{}) error {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return err
+	}
+	_, err = plist.Unmarshal(content, v)
+	return err
+}
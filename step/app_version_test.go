@@ -0,0 +1,100 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"howett.net/plist"
+)
+
+func Test_readArchiveAppVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		archiveInfo map[string]interface{}
+		apps        map[string]map[string]interface{}
+		want        AppVersion
+		wantErr     bool
+	}{
+		{
+			name: "single app",
+			apps: map[string]map[string]interface{}{
+				"Sample.app": {"CFBundleShortVersionString": "1.2.0", "CFBundleVersion": "42"},
+			},
+			want: AppVersion{Version: "1.2.0", BuildNumber: "42"},
+		},
+		{
+			name: "multiple apps, primary app referenced by the archive",
+			archiveInfo: map[string]interface{}{
+				"ApplicationProperties": map[string]interface{}{"ApplicationPath": "Applications/Primary.app"},
+			},
+			apps: map[string]map[string]interface{}{
+				"Other.app":   {"CFBundleShortVersionString": "9.9.9", "CFBundleVersion": "1"},
+				"Primary.app": {"CFBundleShortVersionString": "1.2.0", "CFBundleVersion": "42"},
+			},
+			want: AppVersion{Version: "1.2.0", BuildNumber: "42"},
+		},
+		{
+			name: "multiple apps, app matched to the scheme",
+			apps: map[string]map[string]interface{}{
+				"Other.app":  {"CFBundleShortVersionString": "9.9.9", "CFBundleVersion": "1"},
+				"Sample.app": {"CFBundleShortVersionString": "1.2.0", "CFBundleVersion": "42"},
+			},
+			want: AppVersion{Version: "1.2.0", BuildNumber: "42"},
+		},
+		{
+			name: "multiple apps, no match",
+			apps: map[string]map[string]interface{}{
+				"First.app":  {"CFBundleShortVersionString": "1.0.0", "CFBundleVersion": "1"},
+				"Second.app": {"CFBundleShortVersionString": "2.0.0", "CFBundleVersion": "2"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unresolved values fall back to the archive's application properties",
+			archiveInfo: map[string]interface{}{
+				"ApplicationProperties": map[string]interface{}{"CFBundleShortVersionString": "1.2.0", "CFBundleVersion": "42"},
+			},
+			apps: map[string]map[string]interface{}{
+				"Sample.app": {"CFBundleShortVersionString": "$(MARKETING_VERSION)", "CFBundleVersion": "${CURRENT_PROJECT_VERSION}"},
+			},
+			want: AppVersion{Version: "1.2.0", BuildNumber: "42"},
+		},
+		{
+			name: "unresolved values without fallback",
+			apps: map[string]map[string]interface{}{
+				"Sample.app": {"CFBundleShortVersionString": "$(MARKETING_VERSION)", "CFBundleVersion": "42"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "Sample.xcarchive")
+			archiveInfo := tt.archiveInfo
+			if archiveInfo == nil {
+				archiveInfo = map[string]interface{}{}
+			}
+			writePlist(t, filepath.Join(archivePath, "Info.plist"), archiveInfo)
+			for name, info := range tt.apps {
+				writePlist(t, filepath.Join(archivePath, "Products", "Applications", name, "Info.plist"), info)
+			}
+
+			got, err := readArchiveAppVersion(archivePath, "Sample")
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func writePlist(t *testing.T, pth string, content interface{}) {
+	b, err := plist.Marshal(content, plist.XMLFormat)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+	require.NoError(t, os.WriteFile(pth, b, 0644))
+}
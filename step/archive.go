@@ -3,6 +3,7 @@ package step
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -14,26 +15,46 @@ import (
 	"github.com/bitrise-io/go-xcode/xcpretty"
 )
 
-func runArchiveCommandWithRetry(archiveCmd *xcodebuild.CommandBuilder, useXcpretty bool, swiftPackagesPath string, logger log.Logger) (string, error) {
-	output, err := runArchiveCommand(archiveCmd, useXcpretty, logger)
+const xcodebuildArchiveLiveLogFilename = "xcodebuild-archive.live.log"
+
+func runArchiveCommandWithRetry(archiveCmd xcodebuild.CommandModel, useXcpretty bool, swiftPackagesPath string, monitor archiveMonitorOpts, logger log.Logger) (string, error) {
+	output, err := runArchiveCommand(archiveCmd, useXcpretty, monitor, logger)
 	if err != nil && swiftPackagesPath != "" && strings.Contains(output, cache.SwiftPackagesStateInvalid) {
 		logger.Warnf("Archive failed, swift packages cache is in an invalid state, error: %s", err)
 		if err := os.RemoveAll(swiftPackagesPath); err != nil {
 			return output, fmt.Errorf("failed to remove invalid Swift package caches, error: %s", err)
 		}
-		return runArchiveCommand(archiveCmd, useXcpretty, logger)
+		return runArchiveCommand(archiveCmd, useXcpretty, monitor, logger)
 	}
 	return output, err
 }
 
-func runArchiveCommand(archiveCmd *xcodebuild.CommandBuilder, useXcpretty bool, logger log.Logger) (string, error) {
+func runArchiveCommand(archiveCmd xcodebuild.CommandModel, useXcpretty bool, monitor archiveMonitorOpts, logger log.Logger) (string, error) {
+	stopHeartbeat := startHeartbeat(logger, "archiving", monitor.HeartbeatInterval)
+	defer stopHeartbeat()
+
+	liveLog := openLiveLog(monitor.LiveLogPath, logger)
+	defer func() {
+		if err := liveLog.Close(); err != nil {
+			logger.Warnf("Failed to close the live xcodebuild log: %s", err)
+		}
+	}()
+
+	var output bytes.Buffer
+	outputActivity := newActivityWriter(io.MultiWriter(&output, &bestEffortWriter{w: liveLog, logger: logger}))
+
 	if useXcpretty {
 		xcprettyCmd := xcpretty.New(archiveCmd)
 
 		logger.TDonef("$ %s", xcprettyCmd.PrintableCmd())
 		logger.Println()
 
-		out, err := xcprettyCmd.Run()
+		stopHangMonitor := startHangMonitor(logger, monitor.HangThreshold, outputActivity.LastWrite, spindumpCapture(monitor.CommandFactory, logger, monitor.HangDiagnosticsDir, outputPathResolver{overwrite: monitor.OverwriteOutputs, logger: logger}))
+		defer stopHangMonitor()
+
+		err := runXcprettyCommand(archiveCmd, *xcprettyCmd, outputActivity, logger)
+		stopHangMonitor()
+		out := output.String()
 		return out, wrapXcodebuildCommandError(xcprettyCmd, out, err)
 	}
 
@@ -41,16 +62,112 @@ func runArchiveCommand(archiveCmd *xcodebuild.CommandBuilder, useXcpretty bool,
 	logger.TDonef("$ %s", archiveCmd.PrintableCmd())
 	logger.Println()
 
-	var output bytes.Buffer
 	archiveRootCmd := archiveCmd.Command()
-	archiveRootCmd.SetStdout(&output)
-	archiveRootCmd.SetStderr(&output)
+	archiveRootCmd.SetStdout(outputActivity)
+	archiveRootCmd.SetStderr(outputActivity)
+
+	stopHangMonitor := startHangMonitor(logger, monitor.HangThreshold, outputActivity.LastWrite, spindumpCapture(monitor.CommandFactory, logger, monitor.HangDiagnosticsDir, outputPathResolver{overwrite: monitor.OverwriteOutputs, logger: logger}))
+	defer stopHangMonitor()
 
 	var err error
-	progress.SimpleProgress(".", time.Minute, func() {
+	if monitor.HeartbeatInterval > 0 {
 		err = archiveRootCmd.Run()
-	})
+	} else {
+		progress.SimpleProgress(".", time.Minute, func() {
+			err = archiveRootCmd.Run()
+		})
+	}
+	stopHeartbeat()
+	stopHangMonitor()
 	out := output.String()
 
 	return output.String(), wrapXcodebuildCommandError(archiveCmd, out, err)
 }
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// bestEffortWriter forwards the writes to the wrapped writer until its first failure, which is logged once,
+// so that a failing live log (for example on a full disk) does not abort the archive command.
+type bestEffortWriter struct {
+	w      io.Writer
+	logger log.Logger
+	failed bool
+}
+
+// Write ...
+func (b *bestEffortWriter) Write(p []byte) (int, error) {
+	if b.failed {
+		return len(p), nil
+	}
+	if _, err := b.w.Write(p); err != nil {
+		b.failed = true
+		b.logger.Warnf("Failed to write the live xcodebuild log, the rest of the output is not streamed into it: %s", err)
+	}
+	return len(p), nil
+}
+
+// openLiveLog creates the file the raw xcodebuild output is streamed into while the archive runs, so that the log
+// can be followed during the build, and it is complete even if the Step is killed before exporting the outputs.
+func openLiveLog(pth string, logger log.Logger) io.WriteCloser {
+	if pth == "" {
+		return nopWriteCloser{io.Discard}
+	}
+
+	f, err := os.Create(pth)
+	if err != nil {
+		logger.Warnf("Failed to create the live xcodebuild log: %s", err)
+		return nopWriteCloser{io.Discard}
+	}
+	logger.Printf("Streaming the xcodebuild log to: %s", pth)
+	return f
+}
+
+// runXcprettyCommand pipes the xcodebuild output into xcpretty, like xcpretty.CommandModel.Run,
+// but also tees the raw xcodebuild output into the given writer as it streams.
+func runXcprettyCommand(archiveCmd xcodebuild.CommandModel, xcprettyCmd xcpretty.CommandModel, output io.Writer, logger log.Logger) error {
+	prettyCmd := xcprettyCmd.Command()
+	xcodebuildCmd := archiveCmd.Command()
+
+	pipeReader, pipeWriter := io.Pipe()
+	outWriter := io.MultiWriter(output, pipeWriter)
+
+	xcodebuildCmd.SetStdin(nil)
+	xcodebuildCmd.SetStdout(outWriter)
+	xcodebuildCmd.SetStderr(outWriter)
+
+	prettyCmd.SetStdin(pipeReader)
+	prettyCmd.SetStdout(os.Stdout)
+	prettyCmd.SetStderr(os.Stdout)
+
+	if err := xcodebuildCmd.GetCmd().Start(); err != nil {
+		return err
+	}
+	if err := prettyCmd.GetCmd().Start(); err != nil {
+		// nothing reads the pipe, stop and reap the already running xcodebuild
+		if err := pipeWriter.Close(); err != nil {
+			logger.Warnf("Failed to close xcodebuild-xcpretty pipe, error: %s", err)
+		}
+		if err := xcodebuildCmd.GetCmd().Process.Kill(); err != nil {
+			logger.Warnf("Failed to stop xcodebuild, error: %s", err)
+		}
+		if err := xcodebuildCmd.GetCmd().Wait(); err != nil {
+			logger.Debugf("xcodebuild stopped: %s", err)
+		}
+		return fmt.Errorf("failed to start xcpretty: %w", err)
+	}
+
+	defer func() {
+		if err := pipeWriter.Close(); err != nil {
+			logger.Warnf("Failed to close xcodebuild-xcpretty pipe, error: %s", err)
+		}
+		if err := prettyCmd.GetCmd().Wait(); err != nil {
+			logger.Warnf("xcpretty command failed, error: %s", err)
+		}
+	}()
+
+	return xcodebuildCmd.GetCmd().Wait()
+}
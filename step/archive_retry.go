@@ -0,0 +1,103 @@
+package step
+
+import (
+	"fmt"
+	"time"
+)
+
+// archiveRetryDelay is the wait between two archive attempts.
+const archiveRetryDelay = 30 * time.Second
+
+// ArchiveWithRetryOpts ...
+type ArchiveWithRetryOpts struct {
+	RunOpts RunOpts
+	// Phase prefixes the timer phases of the attempts.
+	Phase string
+	Timer *Timer
+
+	// MaxAttempts is the number of attempts if no retry policy matches the failure.
+	MaxAttempts         int
+	RetryPolicies       RetryPolicies
+	CleanupPlan         RetryCleanupPlan
+	PreserveDerivedData bool
+	KeepFailedArchive   bool
+}
+
+// ArchiveWithRetryResult ...
+type ArchiveWithRetryResult struct {
+	RunResult       RunResult
+	Attempts        int
+	AttemptLogPaths []string
+}
+
+// ArchiveWithRetry runs the archive until it succeeds, or the attempts allowed by the retry count and the retry policies are used up.
+func (s XcodebuildArchiver) ArchiveWithRetry(opts ArchiveWithRetryOpts) (ArchiveWithRetryResult, error) {
+	return s.archiveWithRetry(opts, s.Run)
+}
+
+func (s XcodebuildArchiver) archiveWithRetry(opts ArchiveWithRetryOpts, run func(RunOpts) (RunResult, error)) (ArchiveWithRetryResult, error) {
+	var (
+		out         ArchiveWithRetryResult
+		err         error
+		runOpts     = opts.RunOpts
+		maxAttempts = opts.MaxAttempts
+	)
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		out.Attempts = attempt
+		cleanup := opts.CleanupPlan.CleanupForAttempt(attempt, maxAttempts, opts.RunOpts.PerformCleanAction)
+		if attempt > 1 {
+			s.logger.Infof("Archive attempt %d of %d", attempt, maxAttempts)
+			cleanupResult := s.CleanForRetry(RetryCleanupOpts{
+				ProjectPath: runOpts.ProjectPath,
+				Scheme:      runOpts.Scheme,
+				Tier:        cleanup.Tier,
+
+				XcodebuildPath:      runOpts.XcodebuildPath,
+				PreserveDerivedData: opts.PreserveDerivedData,
+			})
+			if cleanupResult.DisableCache {
+				runOpts.CacheLevel = CacheLevelNone
+			}
+			s.WaitBeforeRetry(archiveRetryDelay)
+		}
+
+		s.logger.Printf("Clean behavior of attempt %d: %s", attempt, cleanup.Description())
+		runOpts.PerformCleanAction = cleanup.CleanAction
+		runOpts.Attempt = attempt
+		stopTimer := opts.Timer.Start(fmt.Sprintf("%s_attempt_%d", opts.Phase, attempt))
+		out.RunResult, err = run(runOpts)
+		stopTimer()
+		if out.RunResult.XcodebuildAttemptLogPath != "" {
+			out.AttemptLogPaths = append(out.AttemptLogPaths, out.RunResult.XcodebuildAttemptLogPath)
+		}
+		if err == nil {
+			break
+		}
+
+		if opts.KeepFailedArchive {
+			s.PreserveFailedArchive(PreserveFailedArchiveOpts{
+				OutputDir:        runOpts.OutputDir,
+				OverwriteOutputs: runOpts.OverwriteOutputs,
+				ArtifactName:     out.RunResult.ArtifactName,
+				ProjectPath:      runOpts.ProjectPath,
+				Attempt:          attempt,
+				ArchivePath:      out.RunResult.ArchivePath,
+			})
+		}
+
+		failureLog := out.RunResult.XcodebuildArchiveLog + "\n" + err.Error()
+		if policy, ok := opts.RetryPolicies.Match(failureLog); ok {
+			maxAttempts = policy.RetryCount + 1
+			s.logger.Printf("Retry policy (%s) matched the failure, allowed retries: %d", policy.Pattern, policy.RetryCount)
+		} else {
+			maxAttempts = opts.MaxAttempts
+		}
+
+		if attempt < maxAttempts {
+			s.logger.Warnf("Archive failed, will retry: %s", err)
+		}
+	}
+
+	return out, err
+}
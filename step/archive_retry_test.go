@@ -0,0 +1,138 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+// failingRun records the options of the attempts and fails the attempts, except for the succeedingAttempt (if set).
+type failingRun struct {
+	runs              []RunOpts
+	succeedingAttempt int
+}
+
+func (r *failingRun) run(opts RunOpts) (RunResult, error) {
+	r.runs = append(r.runs, opts)
+	if opts.Attempt == r.succeedingAttempt {
+		return RunResult{}, nil
+	}
+	return RunResult{XcodebuildArchiveLog: "error: unable to resolve package dependencies"}, errors.New("archive failed")
+}
+
+func TestXcodebuildArchiver_archiveWithRetry_cleanInvocations(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		performCleanAction bool
+		maxAttempts        int
+		want               int
+	}{
+		{performCleanAction: false, maxAttempts: 1, want: 0},
+		{performCleanAction: true, maxAttempts: 1, want: 1},
+		{performCleanAction: false, maxAttempts: 2, want: 0},
+		{performCleanAction: true, maxAttempts: 2, want: 1},
+		{performCleanAction: false, maxAttempts: 3, want: 1},
+		{performCleanAction: true, maxAttempts: 3, want: 2},
+		{performCleanAction: false, maxAttempts: 5, want: 3},
+		{performCleanAction: true, maxAttempts: 5, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("PerformCleanAction: %v, MaxRetryCount: %d", tt.performCleanAction, tt.maxAttempts), func(t *testing.T) {
+			factory := &recordingCommandFactory{}
+			archiver := XcodebuildArchiver{cmdFactory: factory, logger: log.NewLogger(), sleeper: &recordingSleeper{}}
+			run := &failingRun{}
+
+			out, err := archiver.archiveWithRetry(ArchiveWithRetryOpts{
+				RunOpts:     RunOpts{ProjectPath: "Sample.xcodeproj", Scheme: "Sample", PerformCleanAction: tt.performCleanAction},
+				Phase:       "archive",
+				Timer:       NewTimer(),
+				MaxAttempts: tt.maxAttempts,
+				CleanupPlan: DefaultRetryCleanupPlan,
+			}, run.run)
+			require.Error(t, err)
+			require.Equal(t, tt.maxAttempts, out.Attempts)
+			require.Len(t, run.runs, tt.maxAttempts)
+
+			cleans := factory.count("xcodebuild clean")
+			for _, runOpts := range run.runs {
+				if runOpts.PerformCleanAction {
+					cleans++
+				}
+			}
+			require.Equal(t, tt.want, cleans)
+		})
+	}
+}
+
+func TestXcodebuildArchiver_archiveWithRetry_waitsBetweenAttempts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		name              string
+		succeedingAttempt int
+		retryPolicies     RetryPolicies
+		wantAttempts      int
+		wantErr           bool
+		wantWaits         []time.Duration
+	}{
+		{name: "succeeds at the first attempt", succeedingAttempt: 1, wantAttempts: 1, wantWaits: nil},
+		{name: "succeeds at the second attempt", succeedingAttempt: 2, wantAttempts: 2, wantWaits: []time.Duration{archiveRetryDelay}},
+		{name: "fails every attempt", wantAttempts: 3, wantErr: true, wantWaits: []time.Duration{archiveRetryDelay, archiveRetryDelay}},
+		{
+			name:          "retry policy allows more attempts",
+			retryPolicies: RetryPolicies{{Pattern: regexp.MustCompile("unable to resolve package"), RetryCount: 4}},
+			wantAttempts:  5,
+			wantErr:       true,
+			wantWaits:     []time.Duration{archiveRetryDelay, archiveRetryDelay, archiveRetryDelay, archiveRetryDelay},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sleeper := &recordingSleeper{}
+			archiver := XcodebuildArchiver{cmdFactory: &recordingCommandFactory{}, logger: log.NewLogger(), sleeper: sleeper}
+			run := &failingRun{succeedingAttempt: tt.succeedingAttempt}
+
+			out, err := archiver.archiveWithRetry(ArchiveWithRetryOpts{
+				RunOpts:       RunOpts{ProjectPath: "Sample.xcodeproj", Scheme: "Sample"},
+				Phase:         "archive",
+				Timer:         NewTimer(),
+				MaxAttempts:   3,
+				RetryPolicies: tt.retryPolicies,
+				CleanupPlan:   RetryCleanupPlan{CleanupTierClean},
+			}, run.run)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantAttempts, out.Attempts)
+			require.Equal(t, tt.wantWaits, sleeper.waits)
+		})
+	}
+}
+
+func TestXcodebuildArchiver_archiveWithRetry_additionalExportFailure(t *testing.T) {
+	archiver := XcodebuildArchiver{cmdFactory: &recordingCommandFactory{}, logger: log.NewLogger(), sleeper: &recordingSleeper{}}
+	runs := 0
+	run := func(opts RunOpts) (RunResult, error) {
+		runs++
+		return RunResult{AdditionalExportErr: errors.New("failed to export ad-hoc IPA")}, nil
+	}
+
+	out, err := archiver.archiveWithRetry(ArchiveWithRetryOpts{
+		Phase:       "archive",
+		Timer:       NewTimer(),
+		MaxAttempts: 3,
+		CleanupPlan: DefaultRetryCleanupPlan,
+	}, run)
+	require.NoError(t, err)
+	require.Equal(t, 1, runs)
+	require.Equal(t, 1, out.Attempts)
+	require.EqualError(t, out.RunResult.AdditionalExportErr, "failed to export ad-hoc IPA")
+}
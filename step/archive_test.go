@@ -0,0 +1,119 @@
+package step
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/xcpretty"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeArchiveCommand struct {
+	script string
+}
+
+func (c fakeArchiveCommand) PrintableCmd() string    { return "sh -c " + c.script }
+func (c fakeArchiveCommand) Command() *command.Model { return command.New("sh", "-c", c.script) }
+
+func Test_runArchiveCommand_streamsToLiveLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+	}{
+		{name: "succeeded", script: "echo 'Compiling Sample'; echo '** ARCHIVE SUCCEEDED **'"},
+		{name: "failed", script: "echo 'Compiling Sample'; echo 'error: build failed' >&2; exit 65", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			liveLogPath := filepath.Join(t.TempDir(), xcodebuildArchiveLiveLogFilename)
+
+			out, err := runArchiveCommand(fakeArchiveCommand{script: tt.script}, false, archiveMonitorOpts{
+				HeartbeatInterval: time.Hour,
+				LiveLogPath:       liveLogPath,
+			}, log.NewLogger())
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			liveLog, err := os.ReadFile(liveLogPath)
+			require.NoError(t, err)
+			require.Contains(t, out, "Compiling Sample")
+			require.Equal(t, out, string(liveLog))
+		})
+	}
+}
+
+func Test_openLiveLog(t *testing.T) {
+	logger := log.NewLogger()
+
+	disabled := openLiveLog("", logger)
+	_, err := disabled.Write([]byte("ignored"))
+	require.NoError(t, err)
+	require.NoError(t, disabled.Close())
+
+	unwritable := openLiveLog(filepath.Join(t.TempDir(), "missing", "live.log"), logger)
+	_, err = unwritable.Write([]byte("ignored"))
+	require.NoError(t, err)
+	require.NoError(t, unwritable.Close())
+}
+
+func Test_runXcprettyCommand_xcprettyStartFailure(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()+string(os.PathListSeparator)+"/usr/bin:/bin")
+	if _, err := exec.LookPath("xcpretty"); err == nil {
+		t.Skip("xcpretty is installed")
+	}
+
+	pidPath := filepath.Join(t.TempDir(), "xcodebuild.pid")
+	archiveCmd := fakeArchiveCommand{script: "echo $$ > " + pidPath + "; while true; do echo 'Compiling Sample'; done"}
+	done := make(chan error)
+	go func() {
+		done <- runXcprettyCommand(archiveCmd, *xcpretty.New(archiveCmd), io.Discard, log.NewLogger())
+	}()
+
+	select {
+	case err := <-done:
+		require.ErrorContains(t, err, "failed to start xcpretty")
+	case <-time.After(10 * time.Second):
+		t.Fatal("xcodebuild was not stopped after xcpretty failed to start")
+	}
+
+	// the process might have been killed before writing its pid
+	if pid, err := os.ReadFile(pidPath); err == nil && len(bytes.TrimSpace(pid)) > 0 {
+		_, err := os.Stat(filepath.Join("/proc", string(bytes.TrimSpace(pid))))
+		require.True(t, os.IsNotExist(err), "xcodebuild was not reaped")
+	}
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("no space left on device")
+}
+
+func Test_bestEffortWriter(t *testing.T) {
+	failing := &failingWriter{}
+	var output bytes.Buffer
+	w := io.MultiWriter(&output, &bestEffortWriter{w: failing, logger: log.NewLogger()})
+
+	for _, line := range []string{"first\n", "second\n"} {
+		n, err := w.Write([]byte(line))
+		require.NoError(t, err)
+		require.Equal(t, len(line), n)
+	}
+	require.Equal(t, "first\nsecond\n", output.String())
+	require.Equal(t, 1, failing.writes)
+}
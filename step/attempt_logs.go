@@ -0,0 +1,72 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	bitriseXcodebuildAttemptLogsEnvKey = "BITRISE_XCODEBUILD_ATTEMPT_LOGS"
+	bitriseArchiveFlakyEnvKey          = "BITRISE_ARCHIVE_FLAKY"
+	bitriseArchiveAttemptsEnvKey       = "BITRISE_ARCHIVE_ATTEMPTS"
+)
+
+func attemptLogFilename(attempt int) string {
+	return fmt.Sprintf("xcodebuild-archive-attempt-%d.log", attempt)
+}
+
+// saveAttemptLog writes the xcodebuild log of an archive attempt into the OutputDir right after the attempt,
+// so that the logs of the failed attempts are kept when the archive is retried.
+func (s XcodebuildArchiver) saveAttemptLog(outputPaths outputPathResolver, outputDir string, attempt int, log string) string {
+	if attempt < 1 || log == "" {
+		return ""
+	}
+
+	pth, err := outputPaths.resolve(filepath.Join(outputDir, attemptLogFilename(attempt)))
+	if err != nil {
+		s.logger.Warnf("Failed to save the xcodebuild log of attempt %d: %s", attempt, err)
+		return ""
+	}
+	if err := s.fileManager.Write(pth, log, 0644); err != nil {
+		s.logger.Warnf("Failed to save the xcodebuild log of attempt %d: %s", attempt, err)
+		return ""
+	}
+	return pth
+}
+
+func (s XcodebuildArchiver) exportAttemptLogs(logPaths []string) {
+	if len(logPaths) == 0 {
+		return
+	}
+
+	value := strings.Join(logPaths, "|")
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, bitriseXcodebuildAttemptLogsEnvKey, value); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", bitriseXcodebuildAttemptLogsEnvKey, err)
+		return
+	}
+	s.logger.Donef("The xcodebuild log paths of the archive attempts are now available in the Environment Variable: %s (value: %s)", bitriseXcodebuildAttemptLogsEnvKey, value)
+}
+
+// exportArchiveAttempts exports the number of the archive attempt that succeeded, and whether the archive was flaky
+// (succeeded only after a retry). Nothing is exported if every attempt failed.
+func (s XcodebuildArchiver) exportArchiveAttempts(succeededAttempt int) {
+	if succeededAttempt < 1 {
+		return
+	}
+
+	attempts := strconv.Itoa(succeededAttempt)
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, bitriseArchiveAttemptsEnvKey, attempts); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", bitriseArchiveAttemptsEnvKey, err)
+	} else {
+		s.logger.Donef("The number of the succeeded archive attempt is now available in the Environment Variable: %s (value: %s)", bitriseArchiveAttemptsEnvKey, attempts)
+	}
+
+	flaky := strconv.FormatBool(succeededAttempt > 1)
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, bitriseArchiveFlakyEnvKey, flaky); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", bitriseArchiveFlakyEnvKey, err)
+	} else {
+		s.logger.Donef("Whether the archive succeeded only after a retry is now available in the Environment Variable: %s (value: %s)", bitriseArchiveFlakyEnvKey, flaky)
+	}
+}
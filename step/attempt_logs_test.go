@@ -0,0 +1,74 @@
+package step
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/fileutil"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_saveAttemptLog(t *testing.T) {
+	outputDir := t.TempDir()
+	factory := &recordingCommandFactory{}
+	logger := log.NewLogger()
+	archiver := XcodebuildArchiver{cmdFactory: factory, fileManager: fileutil.NewFileManager(), logger: logger}
+	outputPaths := outputPathResolver{overwrite: true, logger: logger}
+
+	first := archiver.saveAttemptLog(outputPaths, outputDir, 1, "first attempt")
+	second := archiver.saveAttemptLog(outputPaths, outputDir, 2, "second attempt")
+	require.Equal(t, filepath.Join(outputDir, "xcodebuild-archive-attempt-1.log"), first)
+	require.Equal(t, filepath.Join(outputDir, "xcodebuild-archive-attempt-2.log"), second)
+
+	content, err := os.ReadFile(first)
+	require.NoError(t, err)
+	require.Equal(t, "first attempt", string(content))
+
+	// the attempt log of a previous archive phase in the same OutputDir is kept, unless the outputs are overwritten
+	kept := archiver.saveAttemptLog(outputPathResolver{logger: logger}, outputDir, 1, "next phase")
+	require.Equal(t, filepath.Join(outputDir, "xcodebuild-archive-attempt-1-1.log"), kept)
+	content, err = os.ReadFile(first)
+	require.NoError(t, err)
+	require.Equal(t, "first attempt", string(content))
+
+	require.Empty(t, archiver.saveAttemptLog(outputPaths, outputDir, 3, ""))
+	require.Empty(t, archiver.saveAttemptLog(outputPaths, outputDir, 0, "no attempt"))
+
+	archiver.exportAttemptLogs(nil)
+	require.Equal(t, 0, factory.count("envman add --key "+bitriseXcodebuildAttemptLogsEnvKey))
+
+	archiver.exportAttemptLogs([]string{first, second})
+	require.Equal(t, 1, factory.count("envman add --key "+bitriseXcodebuildAttemptLogsEnvKey))
+}
+
+func Test_exportArchiveAttempts(t *testing.T) {
+	tests := []struct {
+		name             string
+		succeededAttempt int
+		want             map[string]string
+	}{
+		{name: "every attempt failed", succeededAttempt: 0, want: map[string]string{}},
+		{name: "first attempt", succeededAttempt: 1, want: map[string]string{bitriseArchiveAttemptsEnvKey: "1", bitriseArchiveFlakyEnvKey: "false"}},
+		{name: "after retry", succeededAttempt: 3, want: map[string]string{bitriseArchiveAttemptsEnvKey: "3", bitriseArchiveFlakyEnvKey: "true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := &recordingCommandFactory{}
+			archiver := XcodebuildArchiver{cmdFactory: factory, logger: log.NewLogger()}
+
+			archiver.exportArchiveAttempts(tt.succeededAttempt)
+
+			exported := map[string]string{}
+			for i, cmd := range factory.commands {
+				value, err := io.ReadAll(factory.opts[i].Stdin)
+				require.NoError(t, err)
+				exported[strings.TrimPrefix(cmd, "envman add --key ")] = string(value)
+			}
+			require.Equal(t, tt.want, exported)
+		})
+	}
+}
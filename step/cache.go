@@ -0,0 +1,85 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+
+	steputilscache "github.com/bitrise-io/go-steputils/cache"
+	cache "github.com/bitrise-io/go-xcode/v2/xcodecache"
+)
+
+// CacheLevel defines which build folders are collected for the cache.
+type CacheLevel string
+
+const (
+	// CacheLevelNone disables collecting cache content.
+	CacheLevelNone CacheLevel = "none"
+	// CacheLevelSwiftPackages collects the resolved Swift packages.
+	CacheLevelSwiftPackages CacheLevel = "swift_packages"
+	// CacheLevelAll collects the resolved Swift packages and the project's DerivedData.
+	CacheLevelAll CacheLevel = "all"
+)
+
+// ParseCacheLevel ...
+func ParseCacheLevel(s string) (CacheLevel, error) {
+	switch level := CacheLevel(s); level {
+	case CacheLevelNone, CacheLevelSwiftPackages, CacheLevelAll:
+		return level, nil
+	default:
+		return "", fmt.Errorf("unknown cache level: %s, available levels: %s, %s, %s", s, CacheLevelNone, CacheLevelSwiftPackages, CacheLevelAll)
+	}
+}
+
+// cachePaths returns the paths to include in and exclude from the cache for the given level.
+// The exclude paths use the cache's `!` prefix, which excludes the path from the cache entirely.
+func cachePaths(level CacheLevel, swiftPackagesDir, derivedDataDir string) (include []string, exclude []string) {
+	if level == CacheLevelNone {
+		return nil, nil
+	}
+
+	include = append(include, swiftPackagesDir)
+	// Excluding manifest.db will result in a stable cache, as this file is modified in every build.
+	exclude = append(exclude, "!"+filepath.Join(swiftPackagesDir, "manifest.db"))
+
+	if level == CacheLevelAll && derivedDataDir != "" {
+		include = append(include, derivedDataDir)
+		// Logs and the index are rebuilt on every build, caching them only increases the cache size.
+		exclude = append(exclude, "!"+filepath.Join(derivedDataDir, "Logs"), "!"+filepath.Join(derivedDataDir, "Index.noindex"))
+	}
+
+	return include, exclude
+}
+
+// collectCache marks the build folders of the given cache level to be added to the cache,
+// by exporting them in BITRISE_CACHE_INCLUDE_PATHS and BITRISE_CACHE_EXCLUDE_PATHS for the cache push Step.
+func (s XcodebuildArchiver) collectCache(level CacheLevel, projectPath, clonedSourcePackagesDirPath string) error {
+	if level == CacheLevelNone {
+		return nil
+	}
+
+	projectSwiftPackagesDir, err := cache.NewSwiftPackageCache().SwiftPackagesPath(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to get Swift packages path: %w", err)
+	}
+	// The project's Swift packages are cloned into its DerivedData by default
+	derivedDataDir := filepath.Dir(projectSwiftPackagesDir)
+
+	swiftPackagesDir := projectSwiftPackagesDir
+	if clonedSourcePackagesDirPath != "" {
+		swiftPackagesDir = clonedSourcePackagesDirPath
+	}
+
+	include, exclude := cachePaths(level, swiftPackagesDir, derivedDataDir)
+
+	c := steputilscache.New()
+	c.IncludePath(include...)
+	c.ExcludePath(exclude...)
+	if err := c.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cache paths: %w", err)
+	}
+
+	for _, pth := range include {
+		s.logger.Printf("Marked for caching: %s", pth)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCacheLevel(t *testing.T) {
+	for _, level := range []string{"none", "swift_packages", "all"} {
+		got, err := ParseCacheLevel(level)
+		require.NoError(t, err)
+		require.Equal(t, CacheLevel(level), got)
+	}
+
+	_, err := ParseCacheLevel("derived_data")
+	require.EqualError(t, err, "unknown cache level: derived_data, available levels: none, swift_packages, all")
+}
+
+func Test_cachePaths(t *testing.T) {
+	const (
+		swiftPackagesDir = "/DerivedData/Sample-abc/SourcePackages"
+		derivedDataDir   = "/DerivedData/Sample-abc"
+	)
+
+	tests := []struct {
+		name        string
+		level       CacheLevel
+		wantInclude []string
+		wantExclude []string
+	}{
+		{
+			name:  "none",
+			level: CacheLevelNone,
+		},
+		{
+			name:        "swift_packages",
+			level:       CacheLevelSwiftPackages,
+			wantInclude: []string{swiftPackagesDir},
+			wantExclude: []string{"!/DerivedData/Sample-abc/SourcePackages/manifest.db"},
+		},
+		{
+			name:        "all",
+			level:       CacheLevelAll,
+			wantInclude: []string{swiftPackagesDir, derivedDataDir},
+			wantExclude: []string{
+				"!/DerivedData/Sample-abc/SourcePackages/manifest.db",
+				"!/DerivedData/Sample-abc/Logs",
+				"!/DerivedData/Sample-abc/Index.noindex",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			include, exclude := cachePaths(tt.level, swiftPackagesDir, derivedDataDir)
+			require.Equal(t, tt.wantInclude, include)
+			require.Equal(t, tt.wantExclude, exclude)
+		})
+	}
+}
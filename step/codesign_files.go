@@ -0,0 +1,114 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/v2/stepconf"
+	"github.com/bitrise-io/go-xcode/certificateutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+)
+
+// CodesignFiles are the code signing certificate and provisioning profiles supplied from the repository.
+type CodesignFiles struct {
+	CertificatePath       string
+	CertificatePassphrase stepconf.Secret
+	ProvisioningProfiles  []ProvisioningProfileFile
+}
+
+// ProvisioningProfileFile is a provisioning profile supplied from the repository.
+type ProvisioningProfileFile struct {
+	Path string
+	UUID string
+}
+
+// IsEmpty returns true if no code signing file is supplied.
+func (f CodesignFiles) IsEmpty() bool {
+	return f.CertificatePath == "" && len(f.ProvisioningProfiles) == 0
+}
+
+// certificateURLs returns the certificate and passphrase lists of the automatic code signing,
+// extended with the certificate supplied from the repository as a local (file://) URL.
+func (f CodesignFiles) certificateURLs(certificateURLList string, passphraseList stepconf.Secret) (string, stepconf.Secret) {
+	if f.CertificatePath == "" {
+		return certificateURLList, passphraseList
+	}
+
+	certificateURL := "file://" + f.CertificatePath
+	if strings.TrimSpace(certificateURLList) == "" {
+		return certificateURL, f.CertificatePassphrase
+	}
+	return certificateURLList + "|" + certificateURL, passphraseList + "|" + f.CertificatePassphrase
+}
+
+// validateCodesignCertificate checks that the .p12 certificate can be decrypted with the passphrase,
+// so that a wrong passphrase fails the Step before the archive.
+func validateCodesignCertificate(pth string, passphrase stepconf.Secret) error {
+	certificates, err := certificateutil.CertificatesFromPKCS12File(pth, string(passphrase))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt certificate (%s), check the passphrase: %w", pth, err)
+	}
+	if len(certificates) == 0 {
+		return fmt.Errorf("no certificate found in %s", pth)
+	}
+	return nil
+}
+
+// parseProvisioningProfileFiles parses the newline or pipe separated list of provisioning profile paths.
+func parseProvisioningProfileFiles(list string) ([]ProvisioningProfileFile, error) {
+	paths := strings.FieldsFunc(list, func(r rune) bool {
+		return r == '|' || r == '\n'
+	})
+
+	var profiles []ProvisioningProfileFile
+	for _, pth := range paths {
+		pth = strings.TrimSpace(pth)
+		if pth == "" {
+			continue
+		}
+
+		absPth, err := filepath.Abs(pth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand path (%s): %w", pth, err)
+		}
+		profile, err := profileutil.NewProvisioningProfileInfoFromFile(absPth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse provisioning profile (%s): %w", pth, err)
+		}
+
+		profiles = append(profiles, ProvisioningProfileFile{Path: absPth, UUID: profile.UUID})
+	}
+	return profiles, nil
+}
+
+// provisioningProfilesDir is the directory where Xcode looks for the installed provisioning profiles.
+func provisioningProfilesDir() string {
+	return filepath.Join(os.Getenv("HOME"), "Library", "MobileDevice", "Provisioning Profiles")
+}
+
+// installProvisioningProfiles copies the profiles into the profilesDir, named after their UUID.
+func (s XcodebuildArchiver) installProvisioningProfiles(profiles []ProvisioningProfileFile, profilesDir string) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(profilesDir, 0700); err != nil {
+		return fmt.Errorf("failed to create provisioning profiles dir (%s): %w", profilesDir, err)
+	}
+
+	for _, profile := range profiles {
+		content, err := os.ReadFile(profile.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read provisioning profile (%s): %w", profile.Path, err)
+		}
+
+		pth := filepath.Join(profilesDir, profile.UUID+filepath.Ext(profile.Path))
+		if err := os.WriteFile(pth, content, 0600); err != nil {
+			return fmt.Errorf("failed to install provisioning profile (%s): %w", profile.Path, err)
+		}
+		s.logger.Printf("Installed provisioning profile: %s (%s)", filepath.Base(profile.Path), profile.UUID)
+	}
+	return nil
+}
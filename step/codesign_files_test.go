@@ -0,0 +1,90 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-steputils/v2/stepconf"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/certificateutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodesignFiles_certificateURLs(t *testing.T) {
+	tests := []struct {
+		name           string
+		files          CodesignFiles
+		urlList        string
+		passphraseList stepconf.Secret
+		wantURLs       string
+		wantPassphrase stepconf.Secret
+	}{
+		{
+			name:           "no certificate path",
+			urlList:        "https://example.com/dist.p12",
+			passphraseList: "pass",
+			wantURLs:       "https://example.com/dist.p12",
+			wantPassphrase: "pass",
+		},
+		{
+			name:           "certificate path only",
+			files:          CodesignFiles{CertificatePath: "/repo/dist.p12", CertificatePassphrase: "repo-pass"},
+			wantURLs:       "file:///repo/dist.p12",
+			wantPassphrase: "repo-pass",
+		},
+		{
+			name:           "appended to a certificate without passphrase",
+			files:          CodesignFiles{CertificatePath: "/repo/dist.p12", CertificatePassphrase: "repo-pass"},
+			urlList:        "https://example.com/dev.p12",
+			wantURLs:       "https://example.com/dev.p12|file:///repo/dist.p12",
+			wantPassphrase: "|repo-pass",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urls, passphrases := tt.files.certificateURLs(tt.urlList, tt.passphraseList)
+			require.Equal(t, tt.wantURLs, urls)
+			require.Equal(t, tt.wantPassphrase, passphrases)
+		})
+	}
+}
+
+func Test_validateCodesignCertificate(t *testing.T) {
+	cert, key, err := certificateutil.GenerateTestCertificate(1, "72SA8V3WYL", "Bitrise", "Apple Distribution: Bitrise", time.Now().AddDate(1, 0, 0))
+	require.NoError(t, err)
+	content, err := certificateutil.NewCertificateInfo(*cert, key).EncodeToP12("pass")
+	require.NoError(t, err)
+
+	pth := filepath.Join(t.TempDir(), "dist.p12")
+	require.NoError(t, os.WriteFile(pth, content, 0600))
+
+	require.NoError(t, validateCodesignCertificate(pth, "pass"))
+	require.Error(t, validateCodesignCertificate(pth, "wrong"))
+	require.Error(t, validateCodesignCertificate(filepath.Join(t.TempDir(), "missing.p12"), "pass"))
+}
+
+func Test_parseProvisioningProfileFiles(t *testing.T) {
+	profiles, err := parseProvisioningProfileFiles("")
+	require.NoError(t, err)
+	require.Empty(t, profiles)
+
+	_, err = parseProvisioningProfileFiles(filepath.Join(t.TempDir(), "missing.mobileprovision"))
+	require.Error(t, err)
+}
+
+func Test_installProvisioningProfiles(t *testing.T) {
+	srcDir := t.TempDir()
+	profilePath := filepath.Join(srcDir, "Sample_AdHoc.mobileprovision")
+	require.NoError(t, os.WriteFile(profilePath, []byte("profile"), 0600))
+
+	profilesDir := filepath.Join(t.TempDir(), "Provisioning Profiles")
+	archiver := XcodebuildArchiver{logger: log.NewLogger()}
+	err := archiver.installProvisioningProfiles([]ProvisioningProfileFile{{Path: profilePath, UUID: "c2a1b5f4-uuid"}}, profilesDir)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(profilesDir, "c2a1b5f4-uuid.mobileprovision"))
+	require.NoError(t, err)
+	require.Equal(t, "profile", string(content))
+}
@@ -0,0 +1,68 @@
+package step
+
+import (
+	"fmt"
+	"regexp"
+)
+
+type codesignHintRule struct {
+	pattern *regexp.Regexp
+	hint    func(match []string) string
+}
+
+var codesignHintRules = []codesignHintRule{
+	{
+		pattern: regexp.MustCompile(`No profiles for '([^']+)' were found`),
+		hint: func(match []string) string {
+			return fmt.Sprintf("No provisioning profile is installed for the bundle ID %s: upload a matching profile, or enable automatic code signing (`automatic_code_signing`) to let the Step manage the profiles.", match[1])
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`doesn't match the (entitlements file's value for the application-identifier entitlement|bundle identifier)`),
+		hint: func([]string) string {
+			return "The provisioning profile belongs to a different bundle ID than the target: check the target's PRODUCT_BUNDLE_IDENTIFIER and the profile selected for it."
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`Provisioning profile .* (has expired|is expired)`),
+		hint: func([]string) string {
+			return "The provisioning profile has expired: regenerate it on the Apple Developer Portal and upload the new profile."
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`No (signing certificate|certificate for team) .* (found|matching)`),
+		hint: func([]string) string {
+			return "The signing certificate is not installed: upload the certificate (.p12) with its private key, and check that it matches the profile's team and distribution type."
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`errSecInternalComponent`),
+		hint: func([]string) string {
+			return "codesign can not access the signing key: the keychain is locked or codesign is not allowed to use the key, check the keychain path and password inputs."
+		},
+	},
+}
+
+// codesignHints returns remediation hints for the common code signing errors of an xcodebuild log.
+func codesignHints(log string) []string {
+	var hints []string
+	for _, rule := range codesignHintRules {
+		if match := rule.pattern.FindStringSubmatch(log); match != nil {
+			hints = append(hints, rule.hint(match))
+		}
+	}
+	return hints
+}
+
+func (s XcodebuildArchiver) printCodesignHints(log string) {
+	hints := codesignHints(log)
+	if len(hints) == 0 {
+		return
+	}
+
+	s.logger.Println()
+	s.logger.Warnf("Code signing issues detected:")
+	for _, hint := range hints {
+		s.logger.Warnf("- %s", hint)
+	}
+}
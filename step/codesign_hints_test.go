@@ -0,0 +1,56 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_codesignHints(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want []string
+	}{
+		{
+			name: "no code signing error",
+			log:  "/src/App/View.swift:3:1: error: cannot find 'Foo' in scope",
+			want: nil,
+		},
+		{
+			name: "missing profile",
+			log:  `error: No profiles for 'io.bitrise.Sample' were found: Xcode couldn't find any iOS App Development provisioning profiles matching 'io.bitrise.Sample'. (in target 'Sample' from project 'Sample')`,
+			want: []string{"No provisioning profile is installed for the bundle ID io.bitrise.Sample: upload a matching profile, or enable automatic code signing (`automatic_code_signing`) to let the Step manage the profiles."},
+		},
+		{
+			name: "bundle ID mismatch",
+			log:  `error: Provisioning profile "Sample Development" has app ID "io.bitrise.Other", which does not match the bundle ID "io.bitrise.Sample". The bundle identifier "io.bitrise.Sample" doesn't match the bundle identifier "io.bitrise.Other" of the profile.`,
+			want: []string{"The provisioning profile belongs to a different bundle ID than the target: check the target's PRODUCT_BUNDLE_IDENTIFIER and the profile selected for it."},
+		},
+		{
+			name: "expired profile",
+			log:  `error: Provisioning profile "Sample Distribution" has expired. (in target 'Sample' from project 'Sample')`,
+			want: []string{"The provisioning profile has expired: regenerate it on the Apple Developer Portal and upload the new profile."},
+		},
+		{
+			name: "locked keychain",
+			log: `/Users/vagrant/Library/Developer/Xcode/DerivedData/Sample/Build/Sample.app: errSecInternalComponent
+Command CodeSign failed with a nonzero exit code`,
+			want: []string{"codesign can not access the signing key: the keychain is locked or codesign is not allowed to use the key, check the keychain path and password inputs."},
+		},
+		{
+			name: "multiple errors",
+			log: `error: No profiles for 'io.bitrise.Sample.Widget' were found
+error: No signing certificate "iOS Distribution" found: No "iOS Distribution" signing certificate matching team ID "ABCD1234" with a private key was found.`,
+			want: []string{
+				"No provisioning profile is installed for the bundle ID io.bitrise.Sample.Widget: upload a matching profile, or enable automatic code signing (`automatic_code_signing`) to let the Step manage the profiles.",
+				"The signing certificate is not installed: upload the certificate (.p12) with its private key, and check that it matches the profile's team and distribution type.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, codesignHints(tt.log))
+		})
+	}
+}
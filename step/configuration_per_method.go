@@ -0,0 +1,117 @@
+package step
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/sliceutil"
+	"github.com/bitrise-io/go-xcode/v2/codesign"
+)
+
+// ConfigurationArchive is an additional archive of the scheme with another build configuration,
+// from which the distribution methods mapped to the configuration are exported.
+type ConfigurationArchive struct {
+	Configuration           string
+	ExportMethod            string
+	AdditionalExportMethods []string
+	CodesignManager         *codesign.Manager // nil if automatic code signing is "off"
+	// AdditionalExportCodesignManagers are the code signing managers of the AdditionalExportMethods, nil if automatic code signing is "off"
+	AdditionalExportCodesignManagers map[string]*codesign.Manager
+}
+
+// parseConfigurationPerMethod parses the comma or newline separated list of `<distribution method>=<configuration>` items.
+// Only the exported distribution methods (the primary and the additional ones) can be mapped.
+func parseConfigurationPerMethod(s, primaryMethod string, additionalMethods []string) (map[string]string, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+
+	configurationPerMethod := map[string]string{}
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		method, configuration, found := strings.Cut(field, "=")
+		method, configuration = strings.TrimSpace(method), strings.TrimSpace(configuration)
+		if !found || method == "" || configuration == "" {
+			return nil, fmt.Errorf("invalid item (%s), expected format: <distribution method>=<configuration>", field)
+		}
+		if !sliceutil.IsStringInSlice(method, exportMethods) {
+			return nil, fmt.Errorf("unknown distribution method: %s", method)
+		}
+		if method != primaryMethod && !sliceutil.IsStringInSlice(method, additionalMethods) {
+			return nil, fmt.Errorf("distribution method (%s) is not exported, add it to Additional distribution methods (additional_distribution_methods)", method)
+		}
+		if _, ok := configurationPerMethod[method]; ok {
+			return nil, fmt.Errorf("distribution method (%s) is mapped more than once", method)
+		}
+
+		configurationPerMethod[method] = configuration
+	}
+	return configurationPerMethod, nil
+}
+
+// validateConfigurations checks that the mapped configurations are defined in the project.
+func validateConfigurations(configurationPerMethod map[string]string, available []string) error {
+	for _, configuration := range configurationPerMethod {
+		if !sliceutil.IsStringInSlice(configuration, available) {
+			sorted := append([]string{}, available...)
+			sort.Strings(sorted)
+			return fmt.Errorf("configuration (%s) not found in the project, available configurations: %s", configuration, strings.Join(sorted, ", "))
+		}
+	}
+	return nil
+}
+
+// groupExportsByConfiguration groups the distribution methods by their configuration (defaultConfiguration if not mapped).
+// The primary archive is built from the primary distribution method's configuration and exports the methods sharing it,
+// an additional archive is built for each remaining configuration, in the order of the additional distribution methods.
+func groupExportsByConfiguration(defaultConfiguration, primaryMethod string, additionalMethods []string, configurationPerMethod map[string]string) (ConfigurationArchive, []ConfigurationArchive) {
+	configurationOf := func(method string) string {
+		if configuration, ok := configurationPerMethod[method]; ok {
+			return configuration
+		}
+		return defaultConfiguration
+	}
+
+	primary := ConfigurationArchive{Configuration: configurationOf(primaryMethod), ExportMethod: primaryMethod}
+	var archives []ConfigurationArchive
+	for _, method := range additionalMethods {
+		configuration := configurationOf(method)
+		if configuration == primary.Configuration {
+			primary.AdditionalExportMethods = append(primary.AdditionalExportMethods, method)
+			continue
+		}
+
+		found := false
+		for i := range archives {
+			if archives[i].Configuration == configuration {
+				archives[i].AdditionalExportMethods = append(archives[i].AdditionalExportMethods, method)
+				found = true
+				break
+			}
+		}
+		if !found {
+			archives = append(archives, ConfigurationArchive{Configuration: configuration, ExportMethod: method})
+		}
+	}
+	return primary, archives
+}
+
+// projectConfigurations returns the scheme's archive configuration (if configuration is empty)
+// and the build configurations defined in the scheme's project.
+func projectConfigurations(projectPath, scheme, configuration string) (string, []string, error) {
+	xcodeProj, _, archiveConfiguration, err := OpenArchivableProject(projectPath, scheme, configuration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var configurations []string
+	for _, buildConfiguration := range xcodeProj.Proj.BuildConfigurationList.BuildConfigurations {
+		configurations = append(configurations, buildConfiguration.Name)
+	}
+	return archiveConfiguration, configurations, nil
+}
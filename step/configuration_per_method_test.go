@@ -0,0 +1,90 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseConfigurationPerMethod(t *testing.T) {
+	tests := []struct {
+		name              string
+		value             string
+		additionalMethods []string
+		want              map[string]string
+		wantErr           string
+	}{
+		{name: "empty", value: "", want: map[string]string{}},
+		{
+			name:              "comma and newline separated",
+			value:             "app-store=Release, ad-hoc = Beta\nenterprise=Beta\n",
+			additionalMethods: []string{"ad-hoc", "enterprise"},
+			want:              map[string]string{"app-store": "Release", "ad-hoc": "Beta", "enterprise": "Beta"},
+		},
+		{name: "missing configuration", value: "app-store=", wantErr: "invalid item (app-store=), expected format: <distribution method>=<configuration>"},
+		{name: "missing separator", value: "Release", wantErr: "invalid item (Release), expected format: <distribution method>=<configuration>"},
+		{name: "unknown method", value: "testflight=Release", wantErr: "unknown distribution method: testflight"},
+		{name: "method is not exported", value: "ad-hoc=Beta", wantErr: "distribution method (ad-hoc) is not exported, add it to Additional distribution methods (additional_distribution_methods)"},
+		{name: "duplicated method", value: "app-store=Release\napp-store=Beta", wantErr: "distribution method (app-store) is mapped more than once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseConfigurationPerMethod(tt.value, "app-store", tt.additionalMethods)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_validateConfigurations(t *testing.T) {
+	available := []string{"Release", "Debug", "Beta"}
+	require.NoError(t, validateConfigurations(map[string]string{"app-store": "Release", "ad-hoc": "Beta"}, available))
+	require.EqualError(t, validateConfigurations(map[string]string{"ad-hoc": "Staging"}, available), "configuration (Staging) not found in the project, available configurations: Beta, Debug, Release")
+}
+
+func Test_groupExportsByConfiguration(t *testing.T) {
+	tests := []struct {
+		name                   string
+		additionalMethods      []string
+		configurationPerMethod map[string]string
+		wantPrimary            ConfigurationArchive
+		wantArchives           []ConfigurationArchive
+	}{
+		{
+			name:                   "all methods share the default configuration",
+			additionalMethods:      []string{"ad-hoc"},
+			configurationPerMethod: map[string]string{"ad-hoc": "Release"},
+			wantPrimary:            ConfigurationArchive{Configuration: "Release", ExportMethod: "app-store", AdditionalExportMethods: []string{"ad-hoc"}},
+		},
+		{
+			name:                   "additional methods grouped under another configuration",
+			additionalMethods:      []string{"ad-hoc", "development", "enterprise"},
+			configurationPerMethod: map[string]string{"ad-hoc": "Beta", "enterprise": "Beta"},
+			wantPrimary:            ConfigurationArchive{Configuration: "Release", ExportMethod: "app-store", AdditionalExportMethods: []string{"development"}},
+			wantArchives: []ConfigurationArchive{
+				{Configuration: "Beta", ExportMethod: "ad-hoc", AdditionalExportMethods: []string{"enterprise"}},
+			},
+		},
+		{
+			name:                   "primary method mapped to another configuration",
+			additionalMethods:      []string{"ad-hoc", "development"},
+			configurationPerMethod: map[string]string{"app-store": "AppStore", "development": "Debug"},
+			wantPrimary:            ConfigurationArchive{Configuration: "AppStore", ExportMethod: "app-store"},
+			wantArchives: []ConfigurationArchive{
+				{Configuration: "Release", ExportMethod: "ad-hoc"},
+				{Configuration: "Debug", ExportMethod: "development"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, archives := groupExportsByConfiguration("Release", "app-store", tt.additionalMethods, tt.configurationPerMethod)
+			require.Equal(t, tt.wantPrimary, primary)
+			require.Equal(t, tt.wantArchives, archives)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// UploadCrashlyticsSymbolsOpts ...
+type UploadCrashlyticsSymbolsOpts struct {
+	FirebaseAppID     string
+	UploadSymbolsPath string
+	DSYMDir           string
+	Platform          Platform
+
+	ProjectPath                 string
+	ClonedSourcePackagesDirPath string
+}
+
+// UploadCrashlyticsSymbols uploads each exported dSYM to Firebase Crashlytics, using Crashlytics' upload-symbols tool.
+// If the tool's path is not provided, it is looked up in the project's CocoaPods and Swift Package dependencies.
+func (s XcodebuildArchiver) UploadCrashlyticsSymbols(opts UploadCrashlyticsSymbolsOpts) error {
+	s.logger.Println()
+	s.logger.Infof("Uploading dSYMs to Firebase Crashlytics")
+
+	uploadSymbolsPath := opts.UploadSymbolsPath
+	if uploadSymbolsPath == "" {
+		var err error
+		uploadSymbolsPath, err = s.findUploadSymbols(opts.ProjectPath, opts.ClonedSourcePackagesDirPath)
+		if err != nil {
+			return err
+		}
+	} else if exist, err := s.pathChecker.IsPathExists(uploadSymbolsPath); err != nil {
+		return fmt.Errorf("failed to check if upload-symbols exists at %s: %w", uploadSymbolsPath, err)
+	} else if !exist {
+		return fmt.Errorf("upload-symbols not found at: %s", uploadSymbolsPath)
+	}
+	s.logger.Printf("upload-symbols: %s", uploadSymbolsPath)
+
+	dsymPaths, err := filepath.Glob(filepath.Join(opts.DSYMDir, "*.dSYM"))
+	if err != nil {
+		return fmt.Errorf("failed to search for dSYMs: %w", err)
+	}
+	if len(dsymPaths) == 0 {
+		s.logger.Warnf("No dSYMs found to upload in: %s", opts.DSYMDir)
+		return nil
+	}
+
+	var failed []string
+	for _, dsymPath := range dsymPaths {
+		cmd := s.cmdFactory.Create(uploadSymbolsPath, []string{"--app-id", opts.FirebaseAppID, "--platform", crashlyticsPlatform(opts.Platform), dsymPath}, nil)
+		s.logger.Printf("$ %s", cmd.PrintableCommandArgs())
+
+		out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+		if out != "" {
+			s.logger.Printf(out)
+		}
+		if err != nil {
+			s.logger.Warnf("Failed to upload %s: %s", filepath.Base(dsymPath), err)
+			failed = append(failed, filepath.Base(dsymPath))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to upload %d of %d dSYMs: %v", len(failed), len(dsymPaths), failed)
+	}
+	s.logger.Donef("Uploaded %d dSYMs to Firebase Crashlytics", len(dsymPaths))
+
+	return nil
+}
+
+// crashlyticsPlatform returns upload-symbols' platform argument of the archived platform,
+// upload-symbols only distinguishes iOS, macOS and tvOS, the rest of the platforms are uploaded as iOS.
+func crashlyticsPlatform(platform Platform) string {
+	switch platform {
+	case osX:
+		return "mac"
+	case tvOS:
+		return "tvos"
+	default:
+		return "ios"
+	}
+}
+
+// findUploadSymbols looks up Crashlytics' upload-symbols tool in the project's CocoaPods and Swift Package dependencies.
+func (s XcodebuildArchiver) findUploadSymbols(projectPath, clonedSourcePackagesDirPath string) (string, error) {
+	candidates := []string{
+		filepath.Join(filepath.Dir(projectPath), "Pods", "FirebaseCrashlytics", "upload-symbols"),
+	}
+
+	if clonedSourcePackagesDirPath != "" {
+		candidates = append(candidates, filepath.Join(clonedSourcePackagesDirPath, "checkouts", "firebase-ios-sdk", "Crashlytics", "upload-symbols"))
+	}
+
+	if derivedDataDir, err := findProjectDerivedDataDir(defaultDerivedDataDir(), projectPath); err != nil {
+		s.logger.Warnf("Failed to find the project's DerivedData: %s", err)
+	} else if derivedDataDir != "" {
+		candidates = append(candidates, filepath.Join(derivedDataDir, "SourcePackages", "checkouts", "firebase-ios-sdk", "Crashlytics", "upload-symbols"))
+	}
+
+	for _, candidate := range candidates {
+		if exist, err := s.pathChecker.IsPathExists(candidate); err != nil {
+			return "", fmt.Errorf("failed to check if upload-symbols exists at %s: %w", candidate, err)
+		} else if exist {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("upload-symbols not found in the project's dependencies, searched: %v", candidates)
+}
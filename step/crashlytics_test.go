@@ -0,0 +1,76 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-utils/v2/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findUploadSymbols(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	projectDir := t.TempDir()
+	projectPath := filepath.Join(projectDir, "Sample.xcworkspace")
+	clonedSourcePackagesDir := t.TempDir()
+
+	archiver := XcodebuildArchiver{pathChecker: pathutil.NewPathChecker(), logger: log.NewLogger()}
+
+	_, err := archiver.findUploadSymbols(projectPath, clonedSourcePackagesDir)
+	require.Error(t, err)
+
+	spmUploadSymbols := filepath.Join(clonedSourcePackagesDir, "checkouts", "firebase-ios-sdk", "Crashlytics", "upload-symbols")
+	require.NoError(t, os.MkdirAll(filepath.Dir(spmUploadSymbols), 0755))
+	require.NoError(t, os.WriteFile(spmUploadSymbols, nil, 0755))
+
+	pth, err := archiver.findUploadSymbols(projectPath, clonedSourcePackagesDir)
+	require.NoError(t, err)
+	require.Equal(t, spmUploadSymbols, pth)
+
+	podsUploadSymbols := filepath.Join(projectDir, "Pods", "FirebaseCrashlytics", "upload-symbols")
+	require.NoError(t, os.MkdirAll(filepath.Dir(podsUploadSymbols), 0755))
+	require.NoError(t, os.WriteFile(podsUploadSymbols, nil, 0755))
+
+	pth, err = archiver.findUploadSymbols(projectPath, clonedSourcePackagesDir)
+	require.NoError(t, err)
+	require.Equal(t, podsUploadSymbols, pth)
+}
+
+func TestXcodebuildArchiver_UploadCrashlyticsSymbols(t *testing.T) {
+	tests := []struct {
+		name         string
+		platform     Platform
+		wantPlatform string
+	}{
+		{name: "iOS", platform: iOS, wantPlatform: "ios"},
+		{name: "tvOS", platform: tvOS, wantPlatform: "tvos"},
+		{name: "macOS", platform: osX, wantPlatform: "mac"},
+		{name: "visionOS", platform: visionOS, wantPlatform: "ios"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsymDir := t.TempDir()
+			dsymPath := filepath.Join(dsymDir, "Sample.app.dSYM")
+			require.NoError(t, os.MkdirAll(dsymPath, 0755))
+
+			factory := &recordingCommandFactory{}
+			archiver := XcodebuildArchiver{
+				cmdFactory:  factory,
+				pathChecker: fakePathChecker{existing: []string{"/tools/upload-symbols"}},
+				logger:      log.NewLogger(),
+			}
+
+			err := archiver.UploadCrashlyticsSymbols(UploadCrashlyticsSymbolsOpts{
+				FirebaseAppID:     "1:1234567890:ios:abc123",
+				UploadSymbolsPath: "/tools/upload-symbols",
+				DSYMDir:           dsymDir,
+				Platform:          tt.platform,
+			})
+			require.NoError(t, err)
+			require.Equal(t, []string{"/tools/upload-symbols --app-id 1:1234567890:ios:abc123 --platform " + tt.wantPlatform + " " + dsymPath}, factory.commands)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"howett.net/plist"
+)
+
+// defaultDerivedDataDir returns Xcode's default DerivedData location.
+func defaultDerivedDataDir() string {
+	return filepath.Join(os.Getenv("HOME"), "Library/Developer/Xcode/DerivedData")
+}
+
+// findProjectDerivedDataDir returns the project's directory in the DerivedData root (eg. DerivedData/MyApp-bcdzqkgvpwvbszbnnkoosgquipxz),
+// by matching the WorkspacePath stored in each directory's info.plist against the project path.
+// Returns an empty path if the project was not built yet.
+func findProjectDerivedDataDir(derivedDataRoot, projectPath string) (string, error) {
+	entries, err := os.ReadDir(derivedDataRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to list DerivedData dir (%s): %w", derivedDataRoot, err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(derivedDataRoot, entry.Name())
+		content, err := os.ReadFile(filepath.Join(dir, "info.plist"))
+		if err != nil {
+			continue
+		}
+
+		var info struct {
+			WorkspacePath string `plist:"WorkspacePath"`
+		}
+		if _, err := plist.Unmarshal(content, &info); err != nil {
+			continue
+		}
+
+		if info.WorkspacePath == projectPath {
+			return dir, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,44 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findProjectDerivedDataDir(t *testing.T) {
+	root := t.TempDir()
+	writeDerivedDataInfo(t, root, "Other-abc", "/path/to/Other.xcworkspace")
+	writeDerivedDataInfo(t, root, "Sample-def", "/path/to/Sample.xcworkspace")
+
+	dir, err := findProjectDerivedDataDir(root, "/path/to/Sample.xcworkspace")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "Sample-def"), dir)
+
+	dir, err = findProjectDerivedDataDir(root, "/path/to/Unknown.xcodeproj")
+	require.NoError(t, err)
+	require.Equal(t, "", dir)
+
+	dir, err = findProjectDerivedDataDir(filepath.Join(root, "not-existing"), "/path/to/Sample.xcworkspace")
+	require.NoError(t, err)
+	require.Equal(t, "", dir)
+}
+
+func writeDerivedDataInfo(t *testing.T, root, name, workspacePath string) {
+	dir := filepath.Join(root, name)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	info := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>LastAccessedDate</key>
+	<date>2024-01-01T00:00:00Z</date>
+	<key>WorkspacePath</key>
+	<string>` + workspacePath + `</string>
+</dict>
+</plist>`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "info.plist"), []byte(info), 0644))
+}
@@ -0,0 +1,87 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	bytesInMB = 1024 * 1024
+	// lowDiskSpaceWarningFactor is the multiplier of MinFreeDiskMB, below which a low disk space warning is logged.
+	lowDiskSpaceWarningFactor = 2
+)
+
+// DiskSpaceChecker ...
+type DiskSpaceChecker interface {
+	// FreeSpace returns the free space available for the user in bytes on the volume holding the given path.
+	FreeSpace(path string) (uint64, error)
+}
+
+type statfsDiskSpaceChecker struct {
+}
+
+// NewStatfsDiskSpaceChecker ...
+func NewStatfsDiskSpaceChecker() DiskSpaceChecker {
+	return statfsDiskSpaceChecker{}
+}
+
+// FreeSpace ...
+func (c statfsDiskSpaceChecker) FreeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(existingAncestor(path), &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+// existingAncestor returns the path itself or its closest existing parent directory,
+// the DerivedData dir for example might not exist before the first build.
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// checkFreeDiskSpace measures the free space of the volumes holding the given paths and returns the lowest in MB.
+// Fails if it is below minFreeDiskMB, unless the check is disabled (minFreeDiskMB < 1).
+func (s XcodebuildArchiver) checkFreeDiskSpace(minFreeDiskMB int, paths ...string) (uint64, error) {
+	var (
+		minFreeMB uint64
+		minPath   string
+	)
+	for i, path := range paths {
+		free, err := s.diskSpaceChecker.FreeSpace(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to measure free disk space at %s: %w", path, err)
+		}
+
+		freeMB := free / bytesInMB
+		if i == 0 || freeMB < minFreeMB {
+			minFreeMB = freeMB
+			minPath = path
+		}
+	}
+
+	s.logger.Printf("Free disk space: %d MB (%s)", minFreeMB, minPath)
+
+	if minFreeDiskMB < 1 {
+		return minFreeMB, nil
+	}
+	if minFreeMB < uint64(minFreeDiskMB) {
+		return minFreeMB, fmt.Errorf("not enough free disk space on the volume holding %s: %d MB available, at least %d MB required (MinFreeDiskMB)", minPath, minFreeMB, minFreeDiskMB)
+	}
+	if minFreeMB < uint64(minFreeDiskMB*lowDiskSpaceWarningFactor) {
+		s.logger.Warnf("Low free disk space on the volume holding %s: %d MB available, the archive might run out of disk space", minPath, minFreeMB)
+	}
+
+	return minFreeMB, nil
+}
@@ -0,0 +1,62 @@
+package step
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXcodebuildArchiver_checkFreeDiskSpace(t *testing.T) {
+	tests := []struct {
+		name          string
+		freeSpaceMB   map[string]uint64
+		minFreeDiskMB int
+		want          uint64
+		wantErr       bool
+	}{
+		{name: "check disabled", freeSpaceMB: map[string]uint64{"/dd": 10, "/out": 20}, minFreeDiskMB: 0, want: 10},
+		{name: "enough space", freeSpaceMB: map[string]uint64{"/dd": 5000, "/out": 3000}, minFreeDiskMB: 1000, want: 3000},
+		{name: "low but sufficient space", freeSpaceMB: map[string]uint64{"/dd": 1500, "/out": 3000}, minFreeDiskMB: 1000, want: 1500},
+		{name: "not enough space", freeSpaceMB: map[string]uint64{"/dd": 5000, "/out": 500}, minFreeDiskMB: 1000, want: 500, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := XcodebuildArchiver{
+				diskSpaceChecker: MockDiskSpaceChecker{freeSpaceMB: tt.freeSpaceMB},
+				logger:           log.NewLogger(),
+			}
+
+			got, err := s.checkFreeDiskSpace(tt.minFreeDiskMB, "/dd", "/out")
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestXcodebuildArchiver_checkFreeDiskSpace_measureFails(t *testing.T) {
+	s := XcodebuildArchiver{
+		diskSpaceChecker: MockDiskSpaceChecker{},
+		logger:           log.NewLogger(),
+	}
+
+	_, err := s.checkFreeDiskSpace(0, "/unknown")
+	require.Error(t, err)
+}
+
+type MockDiskSpaceChecker struct {
+	freeSpaceMB map[string]uint64
+}
+
+func (c MockDiskSpaceChecker) FreeSpace(path string) (uint64, error) {
+	free, ok := c.freeSpaceMB[path]
+	if !ok {
+		return 0, errors.New("unknown path")
+	}
+	return free * bytesInMB, nil
+}
@@ -0,0 +1,123 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+	"howett.net/plist"
+)
+
+const (
+	bitriseAppEntitlementsPthEnvKey = "BITRISE_APP_ENTITLEMENTS_PATH"
+	appEntitlementsFilename         = "entitlements.plist"
+)
+
+// EntitlementsSummary is the summary of the entitlements embedded in the exported app, included in the build summary.
+type EntitlementsSummary struct {
+	AppGroups         []string `json:"app_groups"`
+	AssociatedDomains []string `json:"associated_domains"`
+	PushEnvironment   string   `json:"push_environment"`
+}
+
+// ExportEntitlementsOpts ...
+type ExportEntitlementsOpts struct {
+	OutputDir        string
+	IPAPath          string
+	OverwriteOutputs bool
+}
+
+// ExportEntitlements extracts the entitlements embedded in the signed app of the exported IPA, writes them into the OutputDir
+// and exports its path. It is best-effort: failures are logged as warnings and nil is returned.
+func (s XcodebuildArchiver) ExportEntitlements(opts ExportEntitlementsOpts) *EntitlementsSummary {
+	s.logger.Println()
+	s.logger.Infof("Exporting the app's entitlements")
+
+	content, err := s.extractEntitlements(opts.IPAPath)
+	if err != nil {
+		s.logger.Warnf("Failed to extract entitlements: %s", err)
+		return nil
+	}
+
+	outputPaths := outputPathResolver{overwrite: opts.OverwriteOutputs, logger: s.logger}
+	entitlementsPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, appEntitlementsFilename))
+	if err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", bitriseAppEntitlementsPthEnvKey, err)
+		return nil
+	}
+	if err := ExportOutputFileContent(s.cmdFactory, content, entitlementsPath, bitriseAppEntitlementsPthEnvKey); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", bitriseAppEntitlementsPthEnvKey, err)
+		return nil
+	}
+	s.logger.Donef("The entitlements path is now available in the Environment Variable: %s (value: %s)", bitriseAppEntitlementsPthEnvKey, entitlementsPath)
+
+	summary, err := parseEntitlementsSummary([]byte(content))
+	if err != nil {
+		s.logger.Warnf("Failed to parse entitlements: %s", err)
+		return nil
+	}
+	return &summary
+}
+
+func (s XcodebuildArchiver) extractEntitlements(ipaPath string) (string, error) {
+	tmpDir, err := v1pathutil.NormalizedOSTempDirPath("__entitlements__")
+	if err != nil {
+		return "", fmt.Errorf("failed to create tmp dir: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			s.logger.Warnf("Failed to remove tmp dir (%s): %s", tmpDir, err)
+		}
+	}()
+
+	appPath, err := s.unzipIPAApp(ipaPath, tmpDir)
+	if err != nil {
+		return "", err
+	}
+
+	codesignCmd := s.cmdFactory.Create("codesign", []string{"-d", "--entitlements", ":-", appPath}, nil)
+	content, err := codesignCmd.RunAndReturnTrimmedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", codesignCmd.PrintableCommandArgs(), err)
+	}
+	if content == "" {
+		return "", fmt.Errorf("the app has no entitlements")
+	}
+
+	return content, nil
+}
+
+// unzipIPAApp unzips the app (Payload/*.app) of the IPA into the dir and returns the app's path.
+func (s XcodebuildArchiver) unzipIPAApp(ipaPath, dir string) (string, error) {
+	unzipCmd := s.cmdFactory.Create("/usr/bin/unzip", []string{"-q", ipaPath, "Payload/*", "-d", dir}, nil)
+	if out, err := unzipCmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to unzip ipa (%s), output: %s, error: %w", ipaPath, out, err)
+	}
+
+	appPaths, err := filepath.Glob(filepath.Join(dir, "Payload", "*.app"))
+	if err != nil {
+		return "", fmt.Errorf("failed to search for the app in the ipa: %w", err)
+	}
+	if len(appPaths) == 0 {
+		return "", fmt.Errorf("no app found in the ipa: %s", ipaPath)
+	}
+	return appPaths[0], nil
+}
+
+func parseEntitlementsSummary(content []byte) (EntitlementsSummary, error) {
+	var entitlements struct {
+		AppGroups         []string `plist:"com.apple.security.application-groups"`
+		AssociatedDomains []string `plist:"com.apple.developer.associated-domains"`
+		PushEnvironment   string   `plist:"aps-environment"`
+	}
+	if _, err := plist.Unmarshal(content, &entitlements); err != nil {
+		return EntitlementsSummary{}, err
+	}
+
+	return EntitlementsSummary{
+		AppGroups:         entitlements.AppGroups,
+		AssociatedDomains: entitlements.AssociatedDomains,
+		PushEnvironment:   entitlements.PushEnvironment,
+	}, nil
+}
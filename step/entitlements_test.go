@@ -0,0 +1,41 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseEntitlementsSummary(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>application-identifier</key>
+	<string>TEAM.io.bitrise.sample</string>
+	<key>aps-environment</key>
+	<string>production</string>
+	<key>com.apple.developer.associated-domains</key>
+	<array>
+		<string>applinks:bitrise.io</string>
+	</array>
+	<key>com.apple.security.application-groups</key>
+	<array>
+		<string>group.io.bitrise.sample</string>
+		<string>group.io.bitrise.shared</string>
+	</array>
+</dict>
+</plist>`
+
+	summary, err := parseEntitlementsSummary([]byte(content))
+	require.NoError(t, err)
+	require.Equal(t, EntitlementsSummary{
+		AppGroups:         []string{"group.io.bitrise.sample", "group.io.bitrise.shared"},
+		AssociatedDomains: []string{"applinks:bitrise.io"},
+		PushEnvironment:   "production",
+	}, summary)
+
+	summary, err = parseEntitlementsSummary([]byte(`<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><dict></dict></plist>`))
+	require.NoError(t, err)
+	require.Equal(t, EntitlementsSummary{}, summary)
+}
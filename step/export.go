@@ -2,6 +2,7 @@ package step
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -74,6 +75,11 @@ func ExportOutputDirAsZip(cmdFactory command.Factory, sourceDirPth, destinationP
 	if err != nil {
 		return err
 	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			logger.Warnf("Failed to remove tmp dir (%s): %s", tmpDir, err)
+		}
+	}()
 
 	base := filepath.Base(sourceDirPth)
 	tmpZipFilePth := filepath.Join(tmpDir, base+".zip")
@@ -0,0 +1,80 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+)
+
+const (
+	bitriseFailedXCArchiveZipPthEnvKey = "BITRISE_FAILED_XCARCHIVE_ZIP_PATH"
+	bitriseFailedBuildLogsZipPthEnvKey = "BITRISE_FAILED_BUILD_LOGS_ZIP_PATH"
+)
+
+// PreserveFailedArchiveOpts ...
+type PreserveFailedArchiveOpts struct {
+	OutputDir        string
+	OverwriteOutputs bool
+	ArtifactName     string
+	ProjectPath      string
+	Attempt          int
+
+	ArchivePath string
+}
+
+// PreserveFailedArchive copies the partially produced xcarchive and the DerivedData build logs of a failed archive attempt
+// into the OutputDir, so that they survive the cleanup performed before the next attempt.
+func (s XcodebuildArchiver) PreserveFailedArchive(opts PreserveFailedArchiveOpts) {
+	s.logger.Println()
+	s.logger.Infof("Preserving artifacts of the failed archive attempt %d", opts.Attempt)
+
+	artifactName := opts.ArtifactName
+	if artifactName == "" {
+		artifactName = strings.TrimSuffix(filepath.Base(opts.ProjectPath), filepath.Ext(opts.ProjectPath))
+	}
+	prefix := fmt.Sprintf("%s.attempt-%d.failed", artifactName, opts.Attempt)
+	outputPaths := outputPathResolver{overwrite: opts.OverwriteOutputs, logger: s.logger}
+
+	if opts.ArchivePath == "" {
+		s.logger.Printf("No xcarchive was created")
+	} else if exist, err := v1pathutil.IsDirExists(opts.ArchivePath); err != nil {
+		s.logger.Warnf("Failed to check if xcarchive exists: %s", err)
+	} else if !exist {
+		s.logger.Printf("No xcarchive was created at: %s", opts.ArchivePath)
+	} else {
+		if archiveZipPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, prefix+".xcarchive.zip")); err != nil {
+			s.logger.Warnf("Failed to preserve xcarchive: %s", err)
+		} else if err := ExportOutputDirAsZip(s.cmdFactory, opts.ArchivePath, archiveZipPath, bitriseFailedXCArchiveZipPthEnvKey, s.logger); err != nil {
+			s.logger.Warnf("Failed to preserve xcarchive: %s", err)
+		} else {
+			s.logger.Donef("The failed xcarchive zip path is now available in the Environment Variable: %s (value: %s)", bitriseFailedXCArchiveZipPthEnvKey, archiveZipPath)
+		}
+	}
+
+	derivedDataDir, err := findProjectDerivedDataDir(defaultDerivedDataDir(), opts.ProjectPath)
+	if err != nil {
+		s.logger.Warnf("Failed to find the project's DerivedData: %s", err)
+		return
+	}
+	if derivedDataDir == "" {
+		s.logger.Printf("No DerivedData found for the project")
+		return
+	}
+
+	buildLogsDir := filepath.Join(derivedDataDir, "Logs", "Build")
+	if exist, err := v1pathutil.IsDirExists(buildLogsDir); err != nil {
+		s.logger.Warnf("Failed to check if build logs exist: %s", err)
+	} else if !exist {
+		s.logger.Printf("No build logs found in DerivedData: %s", derivedDataDir)
+	} else {
+		if buildLogsZipPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, prefix+".build-logs.zip")); err != nil {
+			s.logger.Warnf("Failed to preserve build logs: %s", err)
+		} else if err := ExportOutputDirAsZip(s.cmdFactory, buildLogsDir, buildLogsZipPath, bitriseFailedBuildLogsZipPthEnvKey, s.logger); err != nil {
+			s.logger.Warnf("Failed to preserve build logs: %s", err)
+		} else {
+			s.logger.Donef("The failed build logs zip path is now available in the Environment Variable: %s (value: %s)", bitriseFailedBuildLogsZipPthEnvKey, buildLogsZipPath)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package step
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+)
+
+const (
+	bitriseEmbeddedFrameworksPthEnvKey = "BITRISE_EMBEDDED_FRAMEWORKS_PATH"
+	embeddedFrameworksFilename         = "frameworks.json"
+
+	frameworkLinkageDynamic = "dynamic"
+	frameworkLinkageStatic  = "static"
+)
+
+var arArchiveMagic = []byte("!<arch>\n")
+
+// EmbeddedFramework is a framework or dynamic library embedded in the exported app.
+type EmbeddedFramework struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"` // relative to the app
+	Identifier  string `json:"identifier,omitempty"`
+	Version     string `json:"version,omitempty"`
+	BuildNumber string `json:"build_number,omitempty"`
+	// Linkage is dynamic or static, empty if the binary's type could not be detected
+	Linkage string `json:"linkage,omitempty"`
+}
+
+type frameworkInfo struct {
+	CFBundleIdentifier         string `plist:"CFBundleIdentifier"`
+	CFBundleExecutable         string `plist:"CFBundleExecutable"`
+	CFBundleShortVersionString string `plist:"CFBundleShortVersionString"`
+	CFBundleVersion            string `plist:"CFBundleVersion"`
+}
+
+// ExportEmbeddedFrameworksOpts ...
+type ExportEmbeddedFrameworksOpts struct {
+	OutputDir        string
+	IPAPath          string
+	OverwriteOutputs bool
+}
+
+// ExportEmbeddedFrameworks lists the frameworks and dynamic libraries embedded in the app of the exported IPA,
+// writes the list into the OutputDir and exports its path. It is best-effort: failures are logged as warnings.
+func (s XcodebuildArchiver) ExportEmbeddedFrameworks(opts ExportEmbeddedFrameworksOpts) {
+	s.logger.Println()
+	s.logger.Infof("Exporting the app's embedded frameworks")
+
+	tmpDir, err := v1pathutil.NormalizedOSTempDirPath("__frameworks__")
+	if err != nil {
+		s.logger.Warnf("Failed to create tmp dir: %s", err)
+		return
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			s.logger.Warnf("Failed to remove tmp dir (%s): %s", tmpDir, err)
+		}
+	}()
+
+	appPath, err := s.unzipIPAApp(opts.IPAPath, tmpDir)
+	if err != nil {
+		s.logger.Warnf("Failed to extract the app: %s", err)
+		return
+	}
+
+	frameworks, err := s.listEmbeddedFrameworks(appPath)
+	if err != nil {
+		s.logger.Warnf("Failed to list the embedded frameworks: %s", err)
+		return
+	}
+
+	content, err := json.MarshalIndent(frameworks, "", "  ")
+	if err != nil {
+		s.logger.Warnf("Failed to encode the embedded frameworks: %s", err)
+		return
+	}
+
+	outputPaths := outputPathResolver{overwrite: opts.OverwriteOutputs, logger: s.logger}
+	frameworksPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, embeddedFrameworksFilename))
+	if err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", bitriseEmbeddedFrameworksPthEnvKey, err)
+		return
+	}
+	if err := ExportOutputFileContent(s.cmdFactory, string(content), frameworksPath, bitriseEmbeddedFrameworksPthEnvKey); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", bitriseEmbeddedFrameworksPthEnvKey, err)
+		return
+	}
+	s.logger.Printf("Found %d embedded frameworks", len(frameworks))
+	s.logger.Donef("The embedded frameworks path is now available in the Environment Variable: %s (value: %s)", bitriseEmbeddedFrameworksPthEnvKey, frameworksPath)
+}
+
+// listEmbeddedFrameworks lists the frameworks and dynamic libraries (eg. SwiftPM dynamic products) in the Frameworks dir
+// of the app and of its app extensions. A framework with an unreadable Info.plist is listed without its identifier and version.
+func (s XcodebuildArchiver) listEmbeddedFrameworks(appPath string) ([]EmbeddedFramework, error) {
+	var frameworksDirs []string
+	frameworksDirs = append(frameworksDirs, filepath.Join(appPath, "Frameworks"))
+	extensionFrameworksDirs, err := filepath.Glob(filepath.Join(appPath, "PlugIns", "*.appex", "Frameworks"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for the app extensions' frameworks: %w", err)
+	}
+	frameworksDirs = append(frameworksDirs, extensionFrameworksDirs...)
+
+	frameworks := []EmbeddedFramework{}
+	for _, dir := range frameworksDirs {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			pth := filepath.Join(dir, entry.Name())
+			relPath, err := filepath.Rel(appPath, pth)
+			if err != nil {
+				return nil, err
+			}
+
+			switch filepath.Ext(entry.Name()) {
+			case ".framework":
+				frameworks = append(frameworks, s.readEmbeddedFramework(pth, relPath))
+			case ".dylib":
+				frameworks = append(frameworks, EmbeddedFramework{
+					Name:    strings.TrimSuffix(entry.Name(), ".dylib"),
+					Path:    relPath,
+					Linkage: machOLinkage(pth),
+				})
+			}
+		}
+	}
+
+	sort.Slice(frameworks, func(i, j int) bool {
+		return frameworks[i].Path < frameworks[j].Path
+	})
+	return frameworks, nil
+}
+
+func (s XcodebuildArchiver) readEmbeddedFramework(frameworkPath, relPath string) EmbeddedFramework {
+	name := strings.TrimSuffix(filepath.Base(frameworkPath), ".framework")
+	framework := EmbeddedFramework{Name: name, Path: relPath}
+
+	var info frameworkInfo
+	if err := readPlist(filepath.Join(frameworkPath, "Info.plist"), &info); err != nil {
+		s.logger.Warnf("Failed to read the Info.plist of %s: %s", filepath.Base(frameworkPath), err)
+	} else {
+		framework.Identifier = info.CFBundleIdentifier
+		framework.Version = info.CFBundleShortVersionString
+		framework.BuildNumber = info.CFBundleVersion
+	}
+
+	executable := info.CFBundleExecutable
+	if executable == "" {
+		executable = name
+	}
+	framework.Linkage = machOLinkage(filepath.Join(frameworkPath, executable))
+
+	return framework
+}
+
+// machOLinkage returns dynamic for a Mach-O dynamic library, static for a static library (ar archive),
+// and empty if the type of the binary can not be detected.
+func machOLinkage(binaryPath string) string {
+	if f, err := macho.Open(binaryPath); err == nil {
+		defer f.Close()
+		if f.Type == macho.TypeDylib {
+			return frameworkLinkageDynamic
+		}
+		return ""
+	}
+
+	if f, err := macho.OpenFat(binaryPath); err == nil {
+		defer f.Close()
+		if len(f.Arches) > 0 && f.Arches[0].Type == macho.TypeDylib {
+			return frameworkLinkageDynamic
+		}
+		return ""
+	}
+
+	f, err := os.Open(binaryPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(arArchiveMagic))
+	if _, err := io.ReadFull(f, magic); err == nil && bytes.Equal(magic, arArchiveMagic) {
+		return frameworkLinkageStatic
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package step
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMachODylib(t *testing.T, pth string) {
+	header := []uint32{
+		0xfeedfacf, // MH_MAGIC_64
+		0x0100000c, // CPU_TYPE_ARM64
+		0,          // CPU_SUBTYPE_ARM64_ALL
+		6,          // MH_DYLIB
+		0, 0, 0, 0,
+	}
+	f, err := os.Create(pth)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, f.Close()) }()
+	require.NoError(t, binary.Write(f, binary.LittleEndian, header))
+}
+
+func writeFramework(t *testing.T, dir, name, infoPlist string) string {
+	frameworkPath := filepath.Join(dir, name+".framework")
+	require.NoError(t, os.MkdirAll(frameworkPath, 0755))
+	if infoPlist != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(frameworkPath, "Info.plist"), []byte(infoPlist), 0644))
+	}
+	return frameworkPath
+}
+
+func Test_listEmbeddedFrameworks(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "Sample.app")
+	frameworksDir := filepath.Join(appPath, "Frameworks")
+	extensionFrameworksDir := filepath.Join(appPath, "PlugIns", "Widget.appex", "Frameworks")
+	require.NoError(t, os.MkdirAll(frameworksDir, 0755))
+	require.NoError(t, os.MkdirAll(extensionFrameworksDir, 0755))
+
+	alamofire := writeFramework(t, frameworksDir, "Alamofire", `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>CFBundleExecutable</key>
+	<string>Alamofire</string>
+	<key>CFBundleIdentifier</key>
+	<string>org.alamofire.Alamofire</string>
+	<key>CFBundleShortVersionString</key>
+	<string>5.8.1</string>
+	<key>CFBundleVersion</key>
+	<string>1</string>
+</dict>
+</plist>`)
+	writeMachODylib(t, filepath.Join(alamofire, "Alamofire"))
+
+	resources := writeFramework(t, extensionFrameworksDir, "Resources", "")
+	require.NoError(t, os.WriteFile(filepath.Join(resources, "Resources"), []byte("!<arch>\n"), 0644))
+
+	writeMachODylib(t, filepath.Join(frameworksDir, "libswiftCore.dylib"))
+	require.NoError(t, os.WriteFile(filepath.Join(frameworksDir, "README.txt"), []byte("ignored"), 0644))
+
+	archiver := XcodebuildArchiver{logger: log.NewLogger()}
+	frameworks, err := archiver.listEmbeddedFrameworks(appPath)
+	require.NoError(t, err)
+	require.Equal(t, []EmbeddedFramework{
+		{Name: "Alamofire", Path: "Frameworks/Alamofire.framework", Identifier: "org.alamofire.Alamofire", Version: "5.8.1", BuildNumber: "1", Linkage: "dynamic"},
+		{Name: "libswiftCore", Path: "Frameworks/libswiftCore.dylib", Linkage: "dynamic"},
+		{Name: "Resources", Path: "PlugIns/Widget.appex/Frameworks/Resources.framework", Linkage: "static"},
+	}, frameworks)
+}
+
+func Test_listEmbeddedFrameworks_noFrameworks(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "Sample.app")
+	require.NoError(t, os.MkdirAll(appPath, 0755))
+
+	archiver := XcodebuildArchiver{logger: log.NewLogger()}
+	frameworks, err := archiver.listEmbeddedFrameworks(appPath)
+	require.NoError(t, err)
+	require.Empty(t, frameworks)
+}
+
+func Test_machOLinkage(t *testing.T) {
+	dir := t.TempDir()
+
+	dylib := filepath.Join(dir, "dylib")
+	writeMachODylib(t, dylib)
+	require.Equal(t, "dynamic", machOLinkage(dylib))
+
+	static := filepath.Join(dir, "static")
+	require.NoError(t, os.WriteFile(static, []byte("!<arch>\nobject"), 0644))
+	require.Equal(t, "static", machOLinkage(static))
+
+	unknown := filepath.Join(dir, "unknown")
+	require.NoError(t, os.WriteFile(unknown, []byte("text"), 0644))
+	require.Equal(t, "", machOLinkage(unknown))
+
+	require.Equal(t, "", machOLinkage(filepath.Join(dir, "missing")))
+}
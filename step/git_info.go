@@ -0,0 +1,75 @@
+package step
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const shortCommitLength = 7
+
+// GitInfo is the commit and branch the Step runs on.
+type GitInfo struct {
+	Commit string `json:"commit,omitempty"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// ShortCommit ...
+func (i GitInfo) ShortCommit() string {
+	if len(i.Commit) > shortCommitLength {
+		return i.Commit[:shortCommitLength]
+	}
+	return i.Commit
+}
+
+// resolveGitInfo uses the Bitrise provided commit and branch, and falls back to the git repository of the project.
+func (s XcodebuildArchiver) resolveGitInfo(commit, branch, projectPath string) GitInfo {
+	info := GitInfo{Commit: commit, Branch: branch}
+	dir := filepath.Dir(projectPath)
+
+	if info.Commit == "" {
+		info.Commit = s.gitOutput(dir, "rev-parse", "HEAD")
+	}
+	if info.Branch == "" {
+		// HEAD is printed when the repository is in detached HEAD state
+		if branch := s.gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "HEAD" {
+			info.Branch = branch
+		}
+	}
+
+	return info
+}
+
+func (s XcodebuildArchiver) gitOutput(dir string, args ...string) string {
+	cmd := s.cmdFactory.Create("git", append([]string{"-C", dir}, args...), nil)
+	out, err := cmd.RunAndReturnTrimmedOutput()
+	if err != nil {
+		s.logger.Debugf("Failed to run %s: %s", cmd.PrintableCommandArgs(), err)
+		return ""
+	}
+	return out
+}
+
+// resolveArtifactNamePlaceholders replaces the {commit} (short SHA) and {branch} placeholders of the artifact name.
+// Placeholders without a value are resolved to empty, and returned as missing.
+func resolveArtifactNamePlaceholders(name string, info GitInfo) (string, []string) {
+	var missing []string
+	values := []struct {
+		placeholder string
+		value       string
+	}{
+		{placeholder: "{commit}", value: info.ShortCommit()},
+		{placeholder: "{branch}", value: strings.NewReplacer("/", "_", string(filepath.Separator), "_").Replace(info.Branch)},
+	}
+
+	for _, v := range values {
+		if !strings.Contains(name, v.placeholder) {
+			continue
+		}
+		if v.value == "" {
+			missing = append(missing, v.placeholder)
+		}
+		name = strings.ReplaceAll(name, v.placeholder, v.value)
+	}
+
+	return name, missing
+}
@@ -0,0 +1,47 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXcodebuildArchiver_resolveGitInfo(t *testing.T) {
+	factory := &recordingCommandFactory{}
+	archiver := XcodebuildArchiver{cmdFactory: factory, logger: log.NewLogger()}
+
+	info := archiver.resolveGitInfo("0123456789abcdef", "feature/login", "/src/App/App.xcodeproj")
+	require.Equal(t, GitInfo{Commit: "0123456789abcdef", Branch: "feature/login"}, info)
+	require.Empty(t, factory.commands)
+
+	archiver.resolveGitInfo("", "", "/src/App/App.xcodeproj")
+	require.Equal(t, []string{
+		"git -C /src/App rev-parse HEAD",
+		"git -C /src/App rev-parse --abbrev-ref HEAD",
+	}, factory.commands)
+}
+
+func Test_resolveArtifactNamePlaceholders(t *testing.T) {
+	info := GitInfo{Commit: "0123456789abcdef", Branch: "feature/login"}
+
+	tests := []struct {
+		name        string
+		artifact    string
+		info        GitInfo
+		want        string
+		wantMissing []string
+	}{
+		{name: "no placeholders", artifact: "MyApp", info: info, want: "MyApp"},
+		{name: "commit and branch", artifact: "MyApp-{branch}-{commit}", info: info, want: "MyApp-feature_login-0123456"},
+		{name: "short commit", artifact: "MyApp-{commit}", info: GitInfo{Commit: "abc"}, want: "MyApp-abc"},
+		{name: "missing values", artifact: "MyApp-{branch}-{commit}", info: GitInfo{}, want: "MyApp--", wantMissing: []string{"{commit}", "{branch}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := resolveArtifactNamePlaceholders(tt.artifact, tt.info)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantMissing, missing)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+const (
+	hangDiagnosticsMaxCaptures = 3
+	spindumpDurationSeconds    = "10"
+	spindumpIntervalMillis     = "10"
+)
+
+// hangDiagnosticsProcesses are the processes sampled when the archive appears hung.
+var hangDiagnosticsProcesses = []string{"xcodebuild", "swift-frontend"}
+
+// archiveMonitorOpts configures the monitors running alongside the archive command.
+type archiveMonitorOpts struct {
+	HeartbeatInterval time.Duration
+	// HangThreshold is the duration without output, after which the archive is considered hung, 0 disables the hang monitor.
+	HangThreshold      time.Duration
+	HangDiagnosticsDir string
+	// CommandFactory creates the hang diagnostics commands.
+	CommandFactory command.Factory
+	// OverwriteOutputs replaces the existing hang reports instead of saving the new reports next to them.
+	OverwriteOutputs bool
+	// LiveLogPath is the file the raw xcodebuild output is streamed into, empty disables the live log.
+	LiveLogPath string
+}
+
+// activityWriter records the time of the last write to the wrapped writer.
+type activityWriter struct {
+	w         io.Writer
+	mu        sync.Mutex
+	lastWrite time.Time
+}
+
+func newActivityWriter(w io.Writer) *activityWriter {
+	return &activityWriter{w: w, lastWrite: time.Now()}
+}
+
+// Write ...
+func (a *activityWriter) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastWrite = time.Now()
+	return a.w.Write(p)
+}
+
+// LastWrite ...
+func (a *activityWriter) LastWrite() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastWrite
+}
+
+// hangCaptureFunc captures diagnostics of a hung archive, it must return when the context is cancelled.
+type hangCaptureFunc func(ctx context.Context, index int)
+
+// startHangMonitor calls capture when lastActivity reports no activity for the threshold duration,
+// once per silent period and at most hangDiagnosticsMaxCaptures times, until the returned stop function is called.
+// The stop function cancels an in-progress capture, waits for the monitoring goroutine to exit, and is safe to be called multiple times.
+func startHangMonitor(logger log.Logger, threshold time.Duration, lastActivity func() time.Time, capture hangCaptureFunc) (stop func()) {
+	if threshold <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(threshold / 4)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		var capturedActivity time.Time
+		captures := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				last := lastActivity()
+				if time.Since(last) < threshold || last.Equal(capturedActivity) || captures >= hangDiagnosticsMaxCaptures {
+					continue
+				}
+
+				captures++
+				capturedActivity = last
+				logger.Warnf("No output for %s, the archive might be hung, capturing diagnostics (%d/%d)", time.Since(last).Round(time.Second), captures, hangDiagnosticsMaxCaptures)
+				capture(ctx, captures)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			cancel()
+			wg.Wait()
+		})
+	}
+}
+
+type spindumpResult struct {
+	out string
+	err error
+}
+
+// spindumpCapture samples the xcodebuild and swift-frontend processes with spindump, and saves the reports into outputDir.
+// The capture returns as soon as the context is cancelled, so that it doesn't block the Step's shutdown,
+// an in-progress spindump exits on its own after its sampling duration.
+func spindumpCapture(cmdFactory command.Factory, logger log.Logger, outputDir string, outputPaths outputPathResolver) hangCaptureFunc {
+	return func(ctx context.Context, index int) {
+		for _, process := range hangDiagnosticsProcesses {
+			if ctx.Err() != nil {
+				return
+			}
+
+			reportPath, err := outputPaths.resolve(filepath.Join(outputDir, fmt.Sprintf("hang-%d-%s.spindump.txt", index, process)))
+			if err != nil {
+				logger.Warnf("Failed to capture spindump of %s: %s", process, err)
+				continue
+			}
+
+			cmd := cmdFactory.Create("sudo", []string{"-n", "spindump", process, spindumpDurationSeconds, spindumpIntervalMillis, "-file", reportPath}, nil)
+			done := make(chan spindumpResult, 1)
+			go func() {
+				out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+				done <- spindumpResult{out: out, err: err}
+			}()
+
+			var result spindumpResult
+			select {
+			case <-ctx.Done():
+				return
+			case result = <-done:
+			}
+
+			if result.err != nil {
+				logger.Warnf("Failed to capture spindump of %s: %s", process, result.err)
+				if result.out != "" {
+					logger.Warnf("Command output: %s", result.out)
+				}
+				continue
+			}
+			logger.Donef("Hang diagnostics of %s saved to: %s", process, reportPath)
+		}
+	}
+}
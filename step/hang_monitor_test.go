@@ -0,0 +1,99 @@
+package step
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_startHangMonitor(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		captures []int
+	)
+	capture := func(ctx context.Context, index int) {
+		mu.Lock()
+		defer mu.Unlock()
+		captures = append(captures, index)
+	}
+	capturedCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(captures)
+	}
+
+	activity := newActivityWriter(&bytes.Buffer{})
+	stop := startHangMonitor(log.NewLogger(), 20*time.Millisecond, activity.LastWrite, capture)
+
+	// a single capture per silent period
+	time.Sleep(80 * time.Millisecond)
+	require.Equal(t, 1, capturedCount())
+
+	// new output starts a new silent period
+	_, err := activity.Write([]byte("output"))
+	require.NoError(t, err)
+	time.Sleep(80 * time.Millisecond)
+	require.Equal(t, 2, capturedCount())
+
+	stop()
+	stop()
+	require.Equal(t, []int{1, 2}, captures)
+}
+
+func Test_startHangMonitor_stopCancelsCapture(t *testing.T) {
+	captureStarted := make(chan struct{})
+	capture := func(ctx context.Context, index int) {
+		close(captureStarted)
+		<-ctx.Done()
+	}
+
+	lastActivity := func() time.Time { return time.Now().Add(-time.Hour) }
+	stop := startHangMonitor(log.NewLogger(), 10*time.Millisecond, lastActivity, capture)
+	<-captureStarted
+
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("stop blocked by the in-progress capture")
+	}
+}
+
+func Test_startHangMonitor_disabled(t *testing.T) {
+	called := false
+	stop := startHangMonitor(log.NewLogger(), 0, time.Now, func(ctx context.Context, index int) { called = true })
+	stop()
+	require.False(t, called)
+}
+
+func Test_spindumpCapture(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "hang-1-xcodebuild.spindump.txt"), nil, 0600))
+
+	factory := &recordingCommandFactory{failPrefix: "sudo -n spindump swift-frontend"}
+	logger := log.NewLogger()
+	capture := spindumpCapture(factory, logger, outputDir, outputPathResolver{logger: logger})
+
+	capture(context.Background(), 1)
+	require.Equal(t, []string{
+		"sudo -n spindump xcodebuild 10 10 -file " + filepath.Join(outputDir, "hang-1-xcodebuild-1.spindump.txt"),
+		"sudo -n spindump swift-frontend 10 10 -file " + filepath.Join(outputDir, "hang-1-swift-frontend.spindump.txt"),
+	}, factory.commands)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	capture(ctx, 2)
+	require.Len(t, factory.commands, 2)
+}
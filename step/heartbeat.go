@@ -0,0 +1,45 @@
+package step
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+// startHeartbeat logs a "still <action>" line every interval, until the returned stop function is called.
+// It keeps CI systems from killing jobs that look idle during long silent phases (eg. "Processing symbol files").
+// The heartbeat is logged as a single line write, so it does not break the lines of the streamed xcodebuild output.
+// The stop function waits for the logging goroutine to exit, and is safe to be called multiple times.
+func startHeartbeat(logger log.Logger, action string, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				logger.Printf("still %s (%ds elapsed)", action, int(time.Since(start).Seconds()))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package step
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_startHeartbeat(t *testing.T) {
+	logger := &recordingLogger{Logger: log.NewLogger()}
+
+	stop := startHeartbeat(logger, "archiving", 10*time.Millisecond)
+	time.Sleep(55 * time.Millisecond)
+	stop()
+	count := logger.count()
+	require.GreaterOrEqual(t, count, 2)
+
+	// no heartbeat after stop, calling stop again is a no-op
+	time.Sleep(30 * time.Millisecond)
+	stop()
+	require.Equal(t, count, logger.count())
+}
+
+func Test_startHeartbeat_disabled(t *testing.T) {
+	logger := &recordingLogger{Logger: log.NewLogger()}
+
+	stop := startHeartbeat(logger, "archiving", 0)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+	require.Equal(t, 0, logger.count())
+}
+
+type recordingLogger struct {
+	log.Logger
+	mu     sync.Mutex
+	prints int
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prints++
+}
+
+func (l *recordingLogger) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.prints
+}
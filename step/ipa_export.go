@@ -1,12 +1,16 @@
 package step
 
 import (
+	"bytes"
+	"io"
+	"os"
+
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-xcode/xcodebuild"
 	v1xcpretty "github.com/bitrise-io/go-xcode/xcpretty"
 )
 
-func runIPAExportCommand(exportCmd *xcodebuild.ExportCommandModel, useXcpretty bool, logger log.Logger) (string, error) {
+func runIPAExportCommand(exportCmd xcodebuild.CommandModel, useXcpretty bool, logger log.Logger) (string, error) {
 	if useXcpretty {
 		xcprettyCmd := v1xcpretty.New(exportCmd)
 
@@ -21,6 +25,15 @@ func runIPAExportCommand(exportCmd *xcodebuild.ExportCommandModel, useXcpretty b
 	logger.TDonef("$ %s", exportCmd.PrintableCmd())
 	logger.Println()
 
-	out, err := exportCmd.RunAndReturnOutput()
+	var outBuffer bytes.Buffer
+	outWriter := io.MultiWriter(&outBuffer, os.Stdout)
+
+	cmd := exportCmd.Command()
+	cmd.SetStdout(outWriter)
+	cmd.SetStderr(outWriter)
+
+	err := cmd.Run()
+	out := outBuffer.String()
+
 	return out, wrapXcodebuildCommandError(exportCmd, out, err)
 }
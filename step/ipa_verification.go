@@ -0,0 +1,94 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"howett.net/plist"
+)
+
+// IPAVerification is the result of the exported IPA's verification, included in the build summary.
+type IPAVerification struct {
+	BundleIdentifier         string `json:"bundle_identifier"`
+	ExpectedBundleIdentifier string `json:"expected_bundle_identifier,omitempty"`
+	SignatureVerified        bool   `json:"signature_verified"`
+	Error                    string `json:"error,omitempty"`
+}
+
+// VerifyIPAOpts ...
+type VerifyIPAOpts struct {
+	IPAPath string
+	// ExpectedBundleIdentifier is compared to the app's CFBundleIdentifier, the comparison is skipped if empty
+	ExpectedBundleIdentifier string
+	TempWorkDir              string
+}
+
+// VerifyIPA checks that the app of the exported IPA has the expected bundle identifier,
+// and that its code signature is valid (codesign --verify --deep --strict).
+func (s XcodebuildArchiver) VerifyIPA(opts VerifyIPAOpts) (IPAVerification, error) {
+	s.logger.Println()
+	s.logger.Infof("Verifying the exported IPA")
+
+	verification := IPAVerification{ExpectedBundleIdentifier: opts.ExpectedBundleIdentifier}
+	err := s.verifyIPA(opts, &verification)
+	if err != nil {
+		verification.Error = err.Error()
+		return verification, err
+	}
+
+	s.logger.Donef("The exported IPA (%s) is signed and its bundle identifier is %s", opts.IPAPath, verification.BundleIdentifier)
+	return verification, nil
+}
+
+func (s XcodebuildArchiver) verifyIPA(opts VerifyIPAOpts, verification *IPAVerification) error {
+	tmpDir, err := newTempDir(opts.TempWorkDir, "__ipa_verification__")
+	if err != nil {
+		return fmt.Errorf("failed to create tmp dir: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			s.logger.Warnf("Failed to remove tmp dir (%s): %s", tmpDir, err)
+		}
+	}()
+
+	appPath, err := s.unzipIPAApp(opts.IPAPath, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	verification.BundleIdentifier, err = readBundleIdentifier(filepath.Join(appPath, "Info.plist"))
+	if err != nil {
+		return err
+	}
+	if opts.ExpectedBundleIdentifier != "" && verification.BundleIdentifier != opts.ExpectedBundleIdentifier {
+		return fmt.Errorf("bundle identifier mismatch: the exported app's bundle identifier is %s, expected %s", verification.BundleIdentifier, opts.ExpectedBundleIdentifier)
+	}
+
+	codesignCmd := s.cmdFactory.Create("codesign", []string{"--verify", "--deep", "--strict", "--verbose=2", appPath}, nil)
+	s.logger.Printf("$ %s", codesignCmd.PrintableCommandArgs())
+	if out, err := codesignCmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("code signature verification of %s failed, output: %s, error: %w", filepath.Base(appPath), out, err)
+	}
+	verification.SignatureVerified = true
+
+	return nil
+}
+
+func readBundleIdentifier(infoPlistPath string) (string, error) {
+	content, err := os.ReadFile(infoPlistPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the app's Info.plist: %w", err)
+	}
+
+	var info struct {
+		BundleIdentifier string `plist:"CFBundleIdentifier"`
+	}
+	if _, err := plist.Unmarshal(content, &info); err != nil {
+		return "", fmt.Errorf("failed to parse the app's Info.plist: %w", err)
+	}
+	if info.BundleIdentifier == "" {
+		return "", fmt.Errorf("no CFBundleIdentifier found in the app's Info.plist")
+	}
+	return info.BundleIdentifier, nil
+}
@@ -0,0 +1,54 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readBundleIdentifier(t *testing.T) {
+	dir := t.TempDir()
+
+	infoPlist := filepath.Join(dir, "Info.plist")
+	require.NoError(t, os.WriteFile(infoPlist, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>io.bitrise.Sample</string>
+</dict>
+</plist>`), 0600))
+	bundleID, err := readBundleIdentifier(infoPlist)
+	require.NoError(t, err)
+	require.Equal(t, "io.bitrise.Sample", bundleID)
+
+	noBundleID := filepath.Join(dir, "NoBundleID.plist")
+	require.NoError(t, os.WriteFile(noBundleID, []byte(`<plist version="1.0"><dict/></plist>`), 0600))
+	_, err = readBundleIdentifier(noBundleID)
+	require.EqualError(t, err, "no CFBundleIdentifier found in the app's Info.plist")
+
+	_, err = readBundleIdentifier(filepath.Join(dir, "Missing.plist"))
+	require.Error(t, err)
+}
+
+func TestVerifyIPA_failureIsRecorded(t *testing.T) {
+	factory := &recordingCommandFactory{failPrefix: "/usr/bin/unzip"}
+	archiver := XcodebuildArchiver{cmdFactory: factory, logger: log.NewLogger()}
+
+	tempWorkDir := t.TempDir()
+	verification, err := archiver.VerifyIPA(VerifyIPAOpts{IPAPath: "Sample.ipa", ExpectedBundleIdentifier: "io.bitrise.Sample", TempWorkDir: tempWorkDir})
+
+	require.ErrorContains(t, err, "failed to unzip ipa (Sample.ipa)")
+	require.Equal(t, IPAVerification{ExpectedBundleIdentifier: "io.bitrise.Sample", Error: err.Error()}, verification)
+	require.Equal(t, 0, factory.count("codesign"))
+
+	// the IPA is extracted into the temp working directory, and removed after the verification
+	require.Len(t, factory.commands, 1)
+	require.Contains(t, factory.commands[0], "-d "+filepath.Join(tempWorkDir, "__ipa_verification__"))
+	entries, err := os.ReadDir(tempWorkDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
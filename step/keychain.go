@@ -0,0 +1,163 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/v2/stepconf"
+	"github.com/bitrise-io/go-utils/errorutil"
+	"github.com/bitrise-io/go-utils/sliceutil"
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-utils/v2/pathutil"
+)
+
+// keychainLockTimeout is the number of seconds, after which the build keychain locks itself.
+// It is raised to outlive a long archive, otherwise codesign prompts for the keychain password and hangs.
+const keychainLockTimeout = "72000"
+
+type buildKeychain struct {
+	path     string
+	password stepconf.Secret
+
+	cmdFactory  command.Factory
+	pathChecker pathutil.PathChecker
+	logger      log.Logger
+
+	created            bool
+	originalSearchList []string
+}
+
+func newBuildKeychain(path string, password stepconf.Secret, cmdFactory command.Factory, pathChecker pathutil.PathChecker, logger log.Logger) *buildKeychain {
+	return &buildKeychain{
+		path:        path,
+		password:    password,
+		cmdFactory:  cmdFactory,
+		pathChecker: pathChecker,
+		logger:      logger,
+	}
+}
+
+// prepare creates the keychain if needed, adds it to the user's keychain search list, unlocks it
+// and raises its lock timeout. The returned error is nil only if the keychain is usable for codesign.
+func (k *buildKeychain) prepare() error {
+	exists, err := k.pathChecker.IsPathExists(k.path)
+	if err != nil {
+		return fmt.Errorf("failed to check if keychain (%s) exists: %w", k.path, err)
+	}
+	if !exists {
+		// Since macOS Sierra keychains are stored with a -db suffix (login.keychain -> login.keychain-db)
+		exists, err = k.pathChecker.IsPathExists(k.path + "-db")
+		if err != nil {
+			return fmt.Errorf("failed to check if keychain (%s) exists: %w", k.path+"-db", err)
+		}
+		if exists {
+			k.path += "-db"
+		}
+	}
+	if !exists {
+		k.logger.Printf("Creating keychain: %s", k.path)
+		if err := k.runSecurityCmd("-v", "create-keychain", "-p", k.password, k.path); err != nil {
+			return err
+		}
+		k.created = true
+	}
+
+	searchList, err := k.searchList()
+	if err != nil {
+		return err
+	}
+	k.originalSearchList = searchList
+
+	if !sliceutil.IsStringInSlice(k.path, searchList) {
+		k.logger.Printf("Adding keychain to the search list")
+		if err := k.runSecurityCmd("-v", "list-keychains", "-d", "user", "-s", append([]string{k.path}, searchList...)); err != nil {
+			return err
+		}
+	}
+
+	k.logger.Printf("Unlocking keychain")
+	if err := k.runSecurityCmd("-v", "unlock-keychain", "-p", k.password, k.path); err != nil {
+		return err
+	}
+
+	return k.runSecurityCmd("-v", "set-keychain-settings", "-lut", keychainLockTimeout, k.path)
+}
+
+// importCertificate imports the .p12 certificate into the keychain, and allows codesign to access its private key
+// without prompting for the keychain password. The keychain has to be prepared before.
+func (k *buildKeychain) importCertificate(pth string, passphrase stepconf.Secret) error {
+	if err := k.runSecurityCmd("import", pth, "-k", k.path, "-P", passphrase, "-A"); err != nil {
+		return err
+	}
+	return k.runSecurityCmd("set-key-partition-list", "-S", "apple-tool:,apple:", "-k", k.password, k.path)
+}
+
+// restore resets the keychain search list to its original state and locks the keychain if it was created by the Step.
+func (k *buildKeychain) restore() {
+	if k.originalSearchList != nil {
+		if err := k.runSecurityCmd("-v", "list-keychains", "-d", "user", "-s", k.originalSearchList); err != nil {
+			k.logger.Warnf("Failed to restore keychain search list: %s", err)
+		}
+	}
+
+	if k.created {
+		if err := k.runSecurityCmd("-v", "lock-keychain", k.path); err != nil {
+			k.logger.Warnf("Failed to lock keychain: %s", err)
+		}
+	}
+}
+
+func (k *buildKeychain) searchList() ([]string, error) {
+	cmd := k.cmdFactory.Create("security", []string{"list-keychains", "-d", "user"}, nil)
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		if errorutil.IsExitStatusError(err) {
+			return nil, fmt.Errorf("%s failed: %s", cmd.PrintableCommandArgs(), out)
+		}
+		return nil, fmt.Errorf("%s failed: %s", cmd.PrintableCommandArgs(), err)
+	}
+
+	return parseKeychainList(out), nil
+}
+
+func (k *buildKeychain) runSecurityCmd(args ...interface{}) error {
+	var printableArgs []string
+	var cmdArgs []string
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case stepconf.Secret:
+			printableArgs = append(printableArgs, v.String())
+			cmdArgs = append(cmdArgs, string(v))
+		case string:
+			printableArgs = append(printableArgs, v)
+			cmdArgs = append(cmdArgs, v)
+		case []string:
+			printableArgs = append(printableArgs, v...)
+			cmdArgs = append(cmdArgs, v...)
+		default:
+			return fmt.Errorf("unknown arg provided: %T, string, []string, and stepconf.Secret are acceptable", arg)
+		}
+	}
+
+	out, err := k.cmdFactory.Create("security", cmdArgs, nil).RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		printableCmd := strings.Join(append([]string{"security"}, printableArgs...), " ")
+		if errorutil.IsExitStatusError(err) {
+			return fmt.Errorf("%s failed: %s", printableCmd, out)
+		}
+		return fmt.Errorf("%s failed: %s", printableCmd, err)
+	}
+	return nil
+}
+
+func parseKeychainList(out string) []string {
+	var keychains []string
+	for _, line := range strings.Split(out, "\n") {
+		keychain := strings.Trim(strings.TrimSpace(line), `"`)
+		if keychain != "" {
+			keychains = append(keychains, keychain)
+		}
+	}
+	return keychains
+}
@@ -0,0 +1,99 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseKeychainList(t *testing.T) {
+	out := `    "/Users/vagrant/Library/Keychains/login.keychain-db"
+    "/Library/Keychains/System.keychain"`
+
+	want := []string{
+		"/Users/vagrant/Library/Keychains/login.keychain-db",
+		"/Library/Keychains/System.keychain",
+	}
+	require.Equal(t, want, parseKeychainList(out))
+	require.Nil(t, parseKeychainList(""))
+}
+
+func Test_buildKeychain_importCertificate(t *testing.T) {
+	factory := &recordingCommandFactory{}
+	keychain := newBuildKeychain("/Users/vagrant/build.keychain", "keychain-pass", factory, nil, log.NewLogger())
+
+	require.NoError(t, keychain.importCertificate("/repo/dist.p12", "cert-pass"))
+	require.Equal(t, []string{
+		"security import /repo/dist.p12 -k /Users/vagrant/build.keychain -P cert-pass -A",
+		"security set-key-partition-list -S apple-tool:,apple: -k keychain-pass /Users/vagrant/build.keychain",
+	}, factory.commands)
+}
+
+func Test_buildKeychain_prepareAndRestore(t *testing.T) {
+	const listKeychains = "security list-keychains -d user"
+	searchList := `    "/Users/vagrant/Library/Keychains/login.keychain-db"`
+
+	tests := []struct {
+		name         string
+		existing     []string
+		searchList   string
+		wantCommands []string
+	}{
+		{
+			name:       "new keychain is created, added to the search list and locked on restore",
+			searchList: searchList,
+			wantCommands: []string{
+				"security -v create-keychain -p keychain-pass /Users/vagrant/build.keychain",
+				listKeychains,
+				"security -v list-keychains -d user -s /Users/vagrant/build.keychain /Users/vagrant/Library/Keychains/login.keychain-db",
+				"security -v unlock-keychain -p keychain-pass /Users/vagrant/build.keychain",
+				"security -v set-keychain-settings -lut 72000 /Users/vagrant/build.keychain",
+				"security -v list-keychains -d user -s /Users/vagrant/Library/Keychains/login.keychain-db",
+				"security -v lock-keychain /Users/vagrant/build.keychain",
+			},
+		},
+		{
+			name:       "existing keychain with -db suffix is unlocked and not locked on restore",
+			existing:   []string{"/Users/vagrant/build.keychain-db"},
+			searchList: searchList,
+			wantCommands: []string{
+				listKeychains,
+				"security -v list-keychains -d user -s /Users/vagrant/build.keychain-db /Users/vagrant/Library/Keychains/login.keychain-db",
+				"security -v unlock-keychain -p keychain-pass /Users/vagrant/build.keychain-db",
+				"security -v set-keychain-settings -lut 72000 /Users/vagrant/build.keychain-db",
+				"security -v list-keychains -d user -s /Users/vagrant/Library/Keychains/login.keychain-db",
+			},
+		},
+		{
+			name:       "keychain already in the search list",
+			existing:   []string{"/Users/vagrant/build.keychain"},
+			searchList: searchList + "\n" + `    "/Users/vagrant/build.keychain"`,
+			wantCommands: []string{
+				listKeychains,
+				"security -v unlock-keychain -p keychain-pass /Users/vagrant/build.keychain",
+				"security -v set-keychain-settings -lut 72000 /Users/vagrant/build.keychain",
+				"security -v list-keychains -d user -s /Users/vagrant/Library/Keychains/login.keychain-db /Users/vagrant/build.keychain",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := &recordingCommandFactory{outputs: map[string]string{listKeychains: tt.searchList}}
+			keychain := newBuildKeychain("/Users/vagrant/build.keychain", "keychain-pass", factory, fakePathChecker{existing: tt.existing}, log.NewLogger())
+
+			require.NoError(t, keychain.prepare())
+			keychain.restore()
+
+			require.Equal(t, tt.wantCommands, factory.commands)
+		})
+	}
+}
+
+func Test_buildKeychain_prepareFailure(t *testing.T) {
+	factory := &recordingCommandFactory{failPrefix: "security -v unlock-keychain"}
+	keychain := newBuildKeychain("/Users/vagrant/build.keychain", "keychain-pass", factory, fakePathChecker{existing: []string{"/Users/vagrant/build.keychain"}}, log.NewLogger())
+
+	err := keychain.prepare()
+	require.EqualError(t, err, "security -v unlock-keychain -p ***** /Users/vagrant/build.keychain failed: exit status 1")
+}
@@ -0,0 +1,83 @@
+package step
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+)
+
+const (
+	bitriseOTAManifestPthEnvKey = "BITRISE_OTA_MANIFEST_PATH"
+	otaManifestFilename         = "manifest.plist"
+)
+
+// otaManifest returns the over-the-air installation manifest options of the export.
+func otaManifest(inputs Inputs) exportoptions.Manifest {
+	return exportoptions.Manifest{
+		AppURL:           inputs.ManifestAppURL,
+		DisplayImageURL:  inputs.ManifestDisplayImageURL,
+		FullSizeImageURL: inputs.ManifestFullSizeImageURL,
+	}
+}
+
+// validateOTAManifest checks that every manifest URL is provided as an https URL, and that the export method supports
+// over-the-air installation (App Store builds can not be installed from a manifest).
+func validateOTAManifest(manifest exportoptions.Manifest, exportMethod string) error {
+	if manifest.IsEmpty() {
+		return nil
+	}
+	if exportMethod == string(exportoptions.MethodAppStore) {
+		return fmt.Errorf("the manifest is not available for %s exports, it is only supported for ad-hoc, enterprise and development exports", exportMethod)
+	}
+
+	for _, u := range []struct {
+		input string
+		value string
+	}{
+		{input: "manifest_app_url", value: manifest.AppURL},
+		{input: "manifest_display_image_url", value: manifest.DisplayImageURL},
+		{input: "manifest_full_size_image_url", value: manifest.FullSizeImageURL},
+	} {
+		if u.value == "" {
+			return fmt.Errorf("%s is required when a manifest URL is provided", u.input)
+		}
+		parsed, err := url.Parse(u.value)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return fmt.Errorf("%s (%s) should be an https URL", u.input, u.value)
+		}
+	}
+	return nil
+}
+
+// applyOTAManifest sets the manifest of the generated export options, so that the export also emits a manifest.plist;
+// App Store export options are left untouched.
+func applyOTAManifest(exportOpts exportoptions.ExportOptions, manifest exportoptions.Manifest) exportoptions.ExportOptions {
+	if options, ok := exportOpts.(exportoptions.NonAppStoreOptionsModel); ok {
+		options.Manifest = manifest
+		return options
+	}
+	return exportOpts
+}
+
+// exportOTAManifest exports the manifest.plist emitted by the IPA export, if any.
+func (s XcodebuildArchiver) exportOTAManifest(ipaExportDir, outputDir string, outputPaths outputPathResolver) error {
+	manifestPath := filepath.Join(ipaExportDir, otaManifestFilename)
+	if exist, err := v1pathutil.IsPathExists(manifestPath); err != nil {
+		return fmt.Errorf("failed to check if %s exists: %w", manifestPath, err)
+	} else if !exist {
+		return nil
+	}
+
+	deployPath, err := outputPaths.resolve(filepath.Join(outputDir, otaManifestFilename))
+	if err != nil {
+		return err
+	}
+	if err := ExportOutputFile(s.cmdFactory, manifestPath, deployPath, bitriseOTAManifestPthEnvKey); err != nil {
+		return fmt.Errorf("failed to export %s: %w", bitriseOTAManifestPthEnvKey, err)
+	}
+	s.logger.Donef("The over-the-air installation manifest path is now available in the Environment Variable: %s (value: %s)", bitriseOTAManifestPthEnvKey, deployPath)
+	return nil
+}
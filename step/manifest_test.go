@@ -0,0 +1,65 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateOTAManifest(t *testing.T) {
+	manifest := exportoptions.Manifest{
+		AppURL:           "https://example.com/app/Sample.ipa",
+		DisplayImageURL:  "https://example.com/app/icon-57.png",
+		FullSizeImageURL: "https://example.com/app/icon-512.png",
+	}
+
+	tests := []struct {
+		name         string
+		manifest     exportoptions.Manifest
+		exportMethod string
+		wantErr      string
+	}{
+		{name: "no manifest", manifest: exportoptions.Manifest{}, exportMethod: "app-store"},
+		{name: "ad-hoc", manifest: manifest, exportMethod: "ad-hoc"},
+		{name: "enterprise", manifest: manifest, exportMethod: "enterprise"},
+		{
+			name:         "app-store",
+			manifest:     manifest,
+			exportMethod: "app-store",
+			wantErr:      "the manifest is not available for app-store exports, it is only supported for ad-hoc, enterprise and development exports",
+		},
+		{
+			name:         "missing image URL",
+			manifest:     exportoptions.Manifest{AppURL: manifest.AppURL},
+			exportMethod: "ad-hoc",
+			wantErr:      "manifest_display_image_url is required when a manifest URL is provided",
+		},
+		{
+			name:         "not https",
+			manifest:     exportoptions.Manifest{AppURL: "http://example.com/Sample.ipa", DisplayImageURL: manifest.DisplayImageURL, FullSizeImageURL: manifest.FullSizeImageURL},
+			exportMethod: "ad-hoc",
+			wantErr:      "manifest_app_url (http://example.com/Sample.ipa) should be an https URL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOTAManifest(tt.manifest, tt.exportMethod)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_applyOTAManifest(t *testing.T) {
+	manifest := exportoptions.Manifest{AppURL: "https://example.com/Sample.ipa"}
+
+	got := applyOTAManifest(exportoptions.NewNonAppStoreOptions(exportoptions.MethodEnterprise), manifest)
+	require.Equal(t, manifest.ToHash(), got.Hash()[exportoptions.ManifestKey])
+
+	appStoreOptions := exportoptions.NewAppStoreOptions()
+	require.Equal(t, appStoreOptions, applyOTAManifest(appStoreOptions, manifest))
+}
@@ -0,0 +1,117 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	v1command "github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+const (
+	bitriseAppClipZipPthListEnvKey      = "BITRISE_APP_CLIP_ZIP_PATH_LIST"
+	bitriseAppExtensionZipPthListEnvKey = "BITRISE_APP_EXTENSION_ZIP_PATH_LIST"
+)
+
+// nestedApps are the App Clips and app extensions embedded in the main app.
+type nestedApps struct {
+	AppClips   []string
+	Extensions []string
+}
+
+// findNestedApps looks for App Clips (AppClips/*.app) and app extensions (PlugIns/*.appex and ExtensionKit's Extensions/*.appex)
+// in the given app directory.
+func findNestedApps(appPath string) (nestedApps, error) {
+	var apps nestedApps
+
+	clips, err := filepath.Glob(filepath.Join(appPath, "AppClips", "*.app"))
+	if err != nil {
+		return nestedApps{}, err
+	}
+	apps.AppClips = clips
+
+	for _, dir := range []string{"PlugIns", "Extensions"} {
+		extensions, err := filepath.Glob(filepath.Join(appPath, dir, "*.appex"))
+		if err != nil {
+			return nestedApps{}, err
+		}
+		apps.Extensions = append(apps.Extensions, extensions...)
+	}
+
+	sort.Strings(apps.AppClips)
+	sort.Strings(apps.Extensions)
+	return apps, nil
+}
+
+// exportNestedApps exports the App Clips and app extensions of the main app as separate zips into the OutputDir.
+// Their dSYMs are part of the exported dSYMs, if ExportAllDsyms is set.
+func (s XcodebuildArchiver) exportNestedApps(appPath, outputDir, artifactName string, outputPaths outputPathResolver) error {
+	s.logger.Printf("Looking for App Clips and app extensions.")
+
+	apps, err := findNestedApps(appPath)
+	if err != nil {
+		return fmt.Errorf("failed to search for nested apps: %w", err)
+	}
+
+	s.logger.Printf("Found %d App Clips and %d app extensions.", len(apps.AppClips), len(apps.Extensions))
+
+	if err := s.exportNestedAppZips(apps.AppClips, outputDir, artifactName, bitriseAppClipZipPthListEnvKey, outputPaths); err != nil {
+		return err
+	}
+	if len(apps.AppClips) > 0 {
+		s.logger.Donef("The App Clip zip paths are now available in the Environment Variable: %s", bitriseAppClipZipPthListEnvKey)
+	}
+
+	if err := s.exportNestedAppZips(apps.Extensions, outputDir, artifactName, bitriseAppExtensionZipPthListEnvKey, outputPaths); err != nil {
+		return err
+	}
+	if len(apps.Extensions) > 0 {
+		s.logger.Donef("The app extension zip paths are now available in the Environment Variable: %s", bitriseAppExtensionZipPthListEnvKey)
+	}
+
+	return nil
+}
+
+func (s XcodebuildArchiver) exportNestedAppZips(appPaths []string, outputDir, artifactName, envKey string, outputPaths outputPathResolver) error {
+	if len(appPaths) == 0 {
+		return nil
+	}
+
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__nested_apps__")
+	if err != nil {
+		return fmt.Errorf("failed to create tmp dir, error: %s", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			s.logger.Warnf("Failed to remove tmp dir (%s): %s", tmpDir, err)
+		}
+	}()
+
+	var zipPaths []string
+	for _, appPath := range appPaths {
+		base := filepath.Base(appPath)
+		tmpZipPath := filepath.Join(tmpDir, base+".zip")
+		if err := zip(s.cmdFactory, appPath, tmpZipPath, s.logger); err != nil {
+			return err
+		}
+
+		zipPath, err := outputPaths.resolve(filepath.Join(outputDir, artifactName+"."+base+".zip"))
+		if err != nil {
+			return err
+		}
+		if err := v1command.CopyFile(tmpZipPath, zipPath); err != nil {
+			return fmt.Errorf("failed to copy (%s) -> (%s), error: %s", tmpZipPath, zipPath, err)
+		}
+		s.logger.Printf("- %s", zipPath)
+
+		zipPaths = append(zipPaths, zipPath)
+	}
+
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, envKey, strings.Join(zipPaths, "|")); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", envKey, err)
+	}
+	return nil
+}
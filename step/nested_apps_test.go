@@ -0,0 +1,40 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findNestedApps(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "Sample.app")
+	require.NoError(t, os.MkdirAll(appPath, 0755))
+
+	apps, err := findNestedApps(appPath)
+	require.NoError(t, err)
+	require.Empty(t, apps.AppClips)
+	require.Empty(t, apps.Extensions)
+
+	for _, pth := range []string{
+		"AppClips/SampleClip.app",
+		"AppClips/OtherClip.app",
+		"PlugIns/Widget.appex",
+		"Extensions/Intents.appex",
+		"Frameworks/Shared.framework",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(appPath, pth), 0755))
+	}
+
+	apps, err = findNestedApps(appPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(appPath, "AppClips/OtherClip.app"),
+		filepath.Join(appPath, "AppClips/SampleClip.app"),
+	}, apps.AppClips)
+	require.Equal(t, []string{
+		filepath.Join(appPath, "Extensions/Intents.appex"),
+		filepath.Join(appPath, "PlugIns/Widget.appex"),
+	}, apps.Extensions)
+}
@@ -0,0 +1,104 @@
+package step
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	notifyFormatGeneric = "generic"
+	notifyFormatSlack   = "slack"
+
+	notifyTimeout = 10 * time.Second
+)
+
+// NotifyOpts ...
+type NotifyOpts struct {
+	WebhookURL string
+	Format     string
+
+	Scheme       string
+	ArtifactName string
+	Succeeded    bool
+	Attempts     int
+	Duration     time.Duration
+}
+
+type notificationPayload struct {
+	Scheme          string  `json:"scheme"`
+	Result          string  `json:"result"`
+	Attempts        int     `json:"attempts"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	ArtifactName    string  `json:"artifact_name"`
+}
+
+type slackNotificationPayload struct {
+	Text string `json:"text"`
+}
+
+// Notify posts the Step's result to the given webhook. It returns an error if the notification could not be delivered,
+// so that the caller can decide how to surface it.
+func (s XcodebuildArchiver) Notify(opts NotifyOpts) error {
+	body, err := notificationBody(opts)
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{Timeout: notifyTimeout}
+	resp, err := client.Post(opts.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.logger.Warnf("Failed to close notification response body: %s", err)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook responded with status: %s", resp.Status)
+	}
+
+	s.logger.Donef("Notification sent")
+	return nil
+}
+
+func notificationBody(opts NotifyOpts) ([]byte, error) {
+	result := "failure"
+	if opts.Succeeded {
+		result = "success"
+	}
+
+	var payload interface{}
+	switch opts.Format {
+	case notifyFormatSlack:
+		icon := ":x:"
+		if opts.Succeeded {
+			icon = ":white_check_mark:"
+		}
+		text := fmt.Sprintf("%s Xcode Archive of *%s*: %s after %d attempt(s) in %s", icon, opts.Scheme, result, opts.Attempts, opts.Duration.Round(time.Second))
+		if opts.ArtifactName != "" {
+			text += fmt.Sprintf(" (artifact: %s)", opts.ArtifactName)
+		}
+		payload = slackNotificationPayload{Text: text}
+	case notifyFormatGeneric, "":
+		payload = notificationPayload{
+			Scheme:          opts.Scheme,
+			Result:          result,
+			Attempts:        opts.Attempts,
+			DurationSeconds: opts.Duration.Seconds(),
+			ArtifactName:    opts.ArtifactName,
+		}
+	default:
+		return nil, fmt.Errorf("unknown notification format: %s", opts.Format)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode notification: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,74 @@
+package step
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_notificationBody(t *testing.T) {
+	tests := []struct {
+		name string
+		opts NotifyOpts
+		want string
+	}{
+		{
+			name: "generic success",
+			opts: NotifyOpts{Format: "generic", Scheme: "Sample", ArtifactName: "Sample", Succeeded: true, Attempts: 1, Duration: 90 * time.Second},
+			want: `{"scheme":"Sample","result":"success","attempts":1,"duration_seconds":90,"artifact_name":"Sample"}`,
+		},
+		{
+			name: "generic failure",
+			opts: NotifyOpts{Format: "generic", Scheme: "Sample", Attempts: 3, Duration: 2 * time.Minute},
+			want: `{"scheme":"Sample","result":"failure","attempts":3,"duration_seconds":120,"artifact_name":""}`,
+		},
+		{
+			name: "slack success",
+			opts: NotifyOpts{Format: "slack", Scheme: "Sample", ArtifactName: "Sample", Succeeded: true, Attempts: 2, Duration: 90500 * time.Millisecond},
+			want: `{"text":":white_check_mark: Xcode Archive of *Sample*: success after 2 attempt(s) in 1m31s (artifact: Sample)"}`,
+		},
+		{
+			name: "slack failure",
+			opts: NotifyOpts{Format: "slack", Scheme: "Sample", Attempts: 1, Duration: time.Minute},
+			want: `{"text":":x: Xcode Archive of *Sample*: failure after 1 attempt(s) in 1m0s"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := notificationBody(tt.opts)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got))
+		})
+	}
+
+	_, err := notificationBody(NotifyOpts{Format: "teams"})
+	require.Error(t, err)
+}
+
+func TestXcodebuildArchiver_Notify(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = string(body)
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	archiver := XcodebuildArchiver{logger: log.NewLogger()}
+	opts := NotifyOpts{WebhookURL: server.URL, Format: "generic", Scheme: "Sample", Succeeded: true, Attempts: 1}
+
+	require.NoError(t, archiver.Notify(opts))
+	require.Contains(t, received, `"result":"success"`)
+
+	opts.WebhookURL = server.URL + "/fail"
+	require.Error(t, archiver.Notify(opts))
+}
@@ -0,0 +1,103 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+const (
+	bitriseOutputDirPthEnvKey = "BITRISE_XCODE_ARCHIVE_OUTPUT_DIR"
+
+	outputDirStrategyFlat   = "flat"
+	outputDirStrategyPerRun = "per-run"
+)
+
+// perRunOutputDir returns the run's own subdirectory under the OutputDir (eg. OutputDir/MyScheme-20240101-120000).
+func perRunOutputDir(outputDir, scheme string, now time.Time) string {
+	name := strings.NewReplacer("/", "_", string(filepath.Separator), "_").Replace(scheme)
+	return filepath.Join(outputDir, name+"-"+now.Format("20060102-150405"))
+}
+
+// prepareOutputDir creates the OutputDir if it is missing and verifies that it is writable by creating a probe file,
+// so that an unusable OutputDir fails the Step before the archive, instead of at the output export.
+func (s XcodebuildArchiver) prepareOutputDir(outputDir string) error {
+	if exist, err := s.pathChecker.IsPathExists(outputDir); err != nil {
+		return fmt.Errorf("failed to check if %s exists: %w", outputDir, err)
+	} else if !exist {
+		if err := os.MkdirAll(outputDir, 0777); err != nil {
+			return fmt.Errorf("failed to create %s: %w", outputDir, err)
+		}
+	} else if isDir, err := s.pathChecker.IsDirExists(outputDir); err != nil {
+		return fmt.Errorf("failed to check if %s is a directory: %w", outputDir, err)
+	} else if !isDir {
+		return fmt.Errorf("%s is not a directory", outputDir)
+	}
+
+	probePath := filepath.Join(outputDir, fmt.Sprintf(".write-probe-%d", os.Getpid()))
+	if err := s.fileManager.Write(probePath, "", 0600); err != nil {
+		return fmt.Errorf("%s is not writable: %w", outputDir, err)
+	}
+	if err := s.fileManager.Remove(probePath); err != nil {
+		s.logger.Warnf("Failed to remove the OutputDir write probe (%s): %s", probePath, err)
+	}
+
+	return nil
+}
+
+// outputExtensions are the multi-part extensions of the outputs, kept in one piece when a numeric suffix is added to the output's name.
+var outputExtensions = []string{".xcarchive.zip", ".dSYM.zip", ".bcsymbolmaps.zip", ".app.zip", ".appex.zip", ".build-logs.zip", ".xcdistributionlogs.zip", ".spindump.txt"}
+
+// splitOutputExtension splits the path into its name and its (known multi-part) extension.
+func splitOutputExtension(pth string) (string, string) {
+	for _, ext := range outputExtensions {
+		if strings.HasSuffix(pth, ext) && len(filepath.Base(pth)) > len(ext) {
+			return strings.TrimSuffix(pth, ext), ext
+		}
+	}
+	ext := filepath.Ext(pth)
+	return strings.TrimSuffix(pth, ext), ext
+}
+
+// outputPathResolver handles the collision of an output with an already existing file in the OutputDir.
+type outputPathResolver struct {
+	overwrite bool
+	logger    log.Logger
+}
+
+// resolve returns the path where the output can be exported to: if the path already exists, it is either removed (overwrite)
+// or a numeric suffix is appended to the output's name.
+func (r outputPathResolver) resolve(pth string) (string, error) {
+	exist, err := v1pathutil.IsPathExists(pth)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if path (%s) exist, error: %s", pth, err)
+	}
+	if !exist {
+		return pth, nil
+	}
+
+	if r.overwrite {
+		if err := os.RemoveAll(pth); err != nil {
+			return "", fmt.Errorf("failed to remove path (%s), error: %s", pth, err)
+		}
+		return pth, nil
+	}
+
+	base, ext := splitOutputExtension(pth)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s-%d%s", base, i, ext)
+		exist, err := v1pathutil.IsPathExists(candidate)
+		if err != nil {
+			return "", fmt.Errorf("failed to check if path (%s) exist, error: %s", candidate, err)
+		}
+		if !exist {
+			r.logger.Warnf("Output already exists at %s, exporting to %s", pth, candidate)
+			return candidate, nil
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/fileutil"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-utils/v2/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_perRunOutputDir(t *testing.T) {
+	now := time.Date(2024, 1, 2, 13, 4, 5, 0, time.UTC)
+	require.Equal(t, "/deploy/My Scheme-20240102-130405", perRunOutputDir("/deploy", "My Scheme", now))
+	require.Equal(t, "/deploy/Feature_Scheme-20240102-130405", perRunOutputDir("/deploy", "Feature/Scheme", now))
+}
+
+func TestXcodebuildArchiver_prepareOutputDir(t *testing.T) {
+	archiver := XcodebuildArchiver{
+		pathChecker: pathutil.NewPathChecker(),
+		fileManager: fileutil.NewFileManager(),
+		logger:      log.NewLogger(),
+	}
+	dir := t.TempDir()
+
+	missingDir := filepath.Join(dir, "deploy", "nested")
+	require.NoError(t, archiver.prepareOutputDir(missingDir))
+	entries, err := os.ReadDir(missingDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	filePath := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(filePath, []byte("file"), 0644))
+	require.EqualError(t, archiver.prepareOutputDir(filePath), filePath+" is not a directory")
+
+	if os.Geteuid() != 0 {
+		readOnlyDir := filepath.Join(dir, "read-only")
+		require.NoError(t, os.Mkdir(readOnlyDir, 0555))
+		require.ErrorContains(t, archiver.prepareOutputDir(readOnlyDir), readOnlyDir+" is not writable")
+	}
+}
+
+func Test_outputPathResolver_resolve(t *testing.T) {
+	dir := t.TempDir()
+	ipaPath := filepath.Join(dir, "MyApp.ipa")
+
+	resolver := outputPathResolver{overwrite: false, logger: log.NewLogger()}
+
+	pth, err := resolver.resolve(ipaPath)
+	require.NoError(t, err)
+	require.Equal(t, ipaPath, pth)
+
+	require.NoError(t, os.WriteFile(ipaPath, []byte("ipa"), 0644))
+	pth, err = resolver.resolve(ipaPath)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "MyApp-1.ipa"), pth)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "MyApp-1.ipa"), []byte("ipa"), 0644))
+	pth, err = resolver.resolve(ipaPath)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "MyApp-2.ipa"), pth)
+
+	resolver.overwrite = true
+	pth, err = resolver.resolve(ipaPath)
+	require.NoError(t, err)
+	require.Equal(t, ipaPath, pth)
+	require.NoFileExists(t, ipaPath)
+}
+
+func Test_outputPathResolver_resolveMultiPartExtension(t *testing.T) {
+	dir := t.TempDir()
+	resolver := outputPathResolver{overwrite: false, logger: log.NewLogger()}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "MyApp.xcarchive.zip", want: "MyApp-1.xcarchive.zip"},
+		{name: "MyApp.dSYM.zip", want: "MyApp-1.dSYM.zip"},
+		{name: "MyApp.attempt-1.failed.build-logs.zip", want: "MyApp.attempt-1.failed-1.build-logs.zip"},
+		{name: "hang-1-xcodebuild.spindump.txt", want: "hang-1-xcodebuild-1.spindump.txt"},
+		{name: "My.App.ipa", want: "My.App-1.ipa"},
+		{name: "build_summary.json", want: "build_summary-1.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(dir, tt.name)
+			require.NoError(t, os.WriteFile(pth, nil, 0644))
+
+			got, err := resolver.resolve(pth)
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(dir, tt.want), got)
+		})
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/bitrise-io/go-utils/v2/command"
 	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/xcodebuild"
 	"github.com/bitrise-io/go-xcode/xcodeproject/schemeint"
 	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
 	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
@@ -22,6 +24,52 @@ const (
 	visionOS Platform = "visionOS"
 )
 
+const minVisionOSXcodeMajorVersion = 15
+
+// platformInputs maps the Platform input values to the platforms and their SDKs.
+var platformInputs = map[string]struct {
+	platform Platform
+	sdk      string
+}{
+	"ios":      {iOS, "iphoneos"},
+	"tvos":     {tvOS, "appletvos"},
+	"watchos":  {watchOS, "watchos"},
+	"macos":    {osX, "macosx"},
+	"visionos": {visionOS, "xros"},
+}
+
+// parsePlatformInput returns the platform and SDK selected by the Platform input, or empty values if the platform is detected from the project.
+func parsePlatformInput(input string) (Platform, string, error) {
+	if input == "" || input == "auto" {
+		return "", "", nil
+	}
+	p, ok := platformInputs[input]
+	if !ok {
+		return "", "", fmt.Errorf("unknown platform: %s", input)
+	}
+	return p.platform, p.sdk, nil
+}
+
+// validatePlatform checks that the platform can be archived with the given Xcode version and exported with the given export method.
+func validatePlatform(platform Platform, exportMethod string, xcodeMajorVersion int) error {
+	switch platform {
+	case osX:
+		if exportMethod == "" {
+			return nil
+		}
+		return fmt.Errorf("macOS apps are distributed as notarized (Developer ID) or Mac App Store packages, which this Step does not export (distribution method: %s), please use a macOS archive Step instead", exportMethod)
+	case watchOS:
+		if exportMethod == "enterprise" {
+			return fmt.Errorf("enterprise distribution method is not available for watchOS apps, available methods: app-store, ad-hoc, development")
+		}
+	case visionOS:
+		if xcodeMajorVersion < minVisionOSXcodeMajorVersion {
+			return fmt.Errorf("visionOS apps require Xcode %d or later, current Xcode major version: %d", minVisionOSXcodeMajorVersion, xcodeMajorVersion)
+		}
+	}
+	return nil
+}
+
 func OpenArchivableProject(pth, schemeName, configurationName string) (*xcodeproj.XcodeProj, *xcscheme.Scheme, string, error) {
 	scheme, schemeContainerDir, err := schemeint.Scheme(pth, schemeName)
 	if err != nil {
@@ -56,11 +104,19 @@ type TargetBuildSettingsProvider interface {
 	TargetBuildSettings(xcodeProj *xcodeproj.XcodeProj, target, configuration string, customOptions ...string) (serialized.Object, error)
 }
 
+// XcodeBuild reads the build settings with the configured xcodebuild binary.
 type XcodeBuild struct {
+	XcodebuildPath string
+	CmdFactory     command.Factory
+	Logger         log.Logger
 }
 
 func (x XcodeBuild) TargetBuildSettings(xcodeProj *xcodeproj.XcodeProj, target, configuration string, customOptions ...string) (serialized.Object, error) {
-	return xcodeProj.TargetBuildSettings(target, configuration, customOptions...)
+	model := xcodebuild.NewShowBuildSettingsCommand(xcodeProj.Path)
+	model.SetTarget(target)
+	model.SetConfiguration(configuration)
+	model.SetCustomOptions(customOptions)
+	return runShowBuildSettings(x.CmdFactory, x.Logger, x.XcodebuildPath, model)
 }
 
 func BuildableTargetPlatform(
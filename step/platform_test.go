@@ -183,3 +183,63 @@ func Test_getPlatform(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePlatformInput(t *testing.T) {
+	tests := []struct {
+		input        string
+		wantPlatform Platform
+		wantSDK      string
+		wantErr      bool
+	}{
+		{input: "auto"},
+		{input: ""},
+		{input: "ios", wantPlatform: iOS, wantSDK: "iphoneos"},
+		{input: "tvos", wantPlatform: tvOS, wantSDK: "appletvos"},
+		{input: "watchos", wantPlatform: watchOS, wantSDK: "watchos"},
+		{input: "macos", wantPlatform: osX, wantSDK: "macosx"},
+		{input: "visionos", wantPlatform: visionOS, wantSDK: "xros"},
+		{input: "android", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			platform, sdk, err := parsePlatformInput(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPlatform, platform)
+			require.Equal(t, tt.wantSDK, sdk)
+		})
+	}
+}
+
+func Test_validatePlatform(t *testing.T) {
+	tests := []struct {
+		name              string
+		platform          Platform
+		exportMethod      string
+		xcodeMajorVersion int
+		wantErr           bool
+	}{
+		{name: "iOS app-store", platform: iOS, exportMethod: "app-store", xcodeMajorVersion: 15},
+		{name: "iOS enterprise", platform: iOS, exportMethod: "enterprise", xcodeMajorVersion: 15},
+		{name: "tvOS ad-hoc", platform: tvOS, exportMethod: "ad-hoc", xcodeMajorVersion: 15},
+		{name: "watchOS app-store", platform: watchOS, exportMethod: "app-store", xcodeMajorVersion: 15},
+		{name: "watchOS enterprise", platform: watchOS, exportMethod: "enterprise", xcodeMajorVersion: 15, wantErr: true},
+		{name: "macOS app-store", platform: osX, exportMethod: "app-store", xcodeMajorVersion: 15, wantErr: true},
+		{name: "macOS without export", platform: osX, exportMethod: "", xcodeMajorVersion: 15},
+		{name: "visionOS with Xcode 15", platform: visionOS, exportMethod: "app-store", xcodeMajorVersion: 15},
+		{name: "visionOS with Xcode 14", platform: visionOS, exportMethod: "app-store", xcodeMajorVersion: 14, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePlatform(tt.platform, tt.exportMethod, tt.xcodeMajorVersion)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
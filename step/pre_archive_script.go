@@ -0,0 +1,60 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+)
+
+const (
+	preArchiveScriptProjectPathEnvKey = "BITRISE_ARCHIVE_PROJECT_PATH"
+	preArchiveScriptAttemptEnvKey     = "BITRISE_ARCHIVE_ATTEMPT"
+)
+
+// runPreArchiveScript runs the user provided script with bash before the archive of the given attempt.
+// The project path and the attempt number are passed to the script as env vars.
+func (s XcodebuildArchiver) runPreArchiveScript(script, projectPath string, attempt int) error {
+	s.logger.Println()
+	s.logger.Infof("Running pre-archive script (attempt %d)", attempt)
+
+	cmd := s.cmdFactory.Create("bash", []string{"-e", "-c", script}, &command.Opts{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Env: []string{
+			preArchiveScriptProjectPathEnvKey + "=" + projectPath,
+			preArchiveScriptAttemptEnvKey + "=" + strconv.Itoa(attempt),
+		},
+	})
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pre-archive script failed: %w", err)
+	}
+	return nil
+}
+
+// isProjectGenerated returns true if the project does not exist yet, so it is expected to be generated by the pre-archive script.
+func (s XcodebuildArchiver) isProjectGenerated(projectPath string) (bool, error) {
+	absProjectPath, err := s.pathModifier.AbsPath(projectPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to get absolute project path: %w", err)
+	}
+	exist, err := s.pathChecker.IsPathExists(absProjectPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if %s exists: %w", absProjectPath, err)
+	}
+	return !exist, nil
+}
+
+// validateGeneratedProject validates the project generated by the pre-archive script and its scheme,
+// and returns the resolved project path. A failure fails the attempt.
+func (s XcodebuildArchiver) validateGeneratedProject(xcodebuildPath, projectPath, scheme string) (string, error) {
+	resolvedProjectPath, err := s.resolveProjectPath(projectPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid project generated by the pre-archive script: %w", err)
+	}
+	if _, err := s.resolveScheme(xcodebuildPath, resolvedProjectPath, scheme); err != nil {
+		return "", fmt.Errorf("invalid scheme of the project generated by the pre-archive script: %w", err)
+	}
+	return resolvedProjectPath, nil
+}
@@ -0,0 +1,60 @@
+package step
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPreArchiveScript(t *testing.T) {
+	factory := &recordingCommandFactory{}
+	archiver := XcodebuildArchiver{cmdFactory: factory, logger: log.NewLogger()}
+
+	require.NoError(t, archiver.runPreArchiveScript("tuist generate", "/project/Sample.xcworkspace", 2))
+
+	require.Equal(t, []string{"bash -e -c tuist generate"}, factory.commands)
+	require.Equal(t, []string{
+		"BITRISE_ARCHIVE_PROJECT_PATH=/project/Sample.xcworkspace",
+		"BITRISE_ARCHIVE_ATTEMPT=2",
+	}, factory.opts[0].Env)
+}
+
+func TestRunPreArchiveScript_failureFailsTheAttempt(t *testing.T) {
+	factory := &recordingCommandFactory{failPrefix: "bash"}
+	archiver := XcodebuildArchiver{cmdFactory: factory, logger: log.NewLogger()}
+
+	err := archiver.runPreArchiveScript("exit 1", "Sample.xcodeproj", 1)
+
+	require.EqualError(t, err, "pre-archive script failed: exit status 1")
+}
+
+func TestXcodebuildArchiver_ArchiveWithRetry_retriesFailedPreArchiveScript(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	outputDir := t.TempDir()
+
+	factory := &recordingCommandFactory{failPrefix: "bash"}
+	sleeper := &recordingSleeper{}
+	diskSpaceChecker := MockDiskSpaceChecker{freeSpaceMB: map[string]uint64{defaultDerivedDataDir(): 10000, outputDir: 10000}}
+	archiver := XcodebuildArchiver{cmdFactory: factory, logger: log.NewLogger(), diskSpaceChecker: diskSpaceChecker, sleeper: sleeper}
+
+	out, err := archiver.ArchiveWithRetry(ArchiveWithRetryOpts{
+		RunOpts:     RunOpts{ProjectPath: "/project/Sample.xcodeproj", Scheme: "Sample", OutputDir: outputDir, PreArchiveScript: "tuist generate"},
+		Phase:       "archive",
+		Timer:       NewTimer(),
+		MaxAttempts: 2,
+		CleanupPlan: DefaultRetryCleanupPlan,
+	})
+
+	require.EqualError(t, err, "pre-archive script failed: exit status 1")
+	require.Equal(t, 2, out.Attempts)
+	require.Equal(t, []time.Duration{archiveRetryDelay}, sleeper.waits)
+	var attempts []string
+	for i, cmd := range factory.commands {
+		if cmd == "bash -e -c tuist generate" {
+			attempts = append(attempts, factory.opts[i].Env[1])
+		}
+	}
+	require.Equal(t, []string{preArchiveScriptAttemptEnvKey + "=1", preArchiveScriptAttemptEnvKey + "=2"}, attempts)
+}
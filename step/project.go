@@ -0,0 +1,41 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// resolveProjectPath returns the absolute, symlink free path of the project or workspace, so that every xcodebuild command
+// (and the DerivedData lookup) refers to the same path.
+func (s XcodebuildArchiver) resolveProjectPath(projectPath string) (string, error) {
+	absProjectPath, err := s.pathModifier.AbsPath(projectPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute project path: %w", err)
+	}
+
+	if exist, err := s.pathChecker.IsPathExists(absProjectPath); err != nil {
+		return "", fmt.Errorf("failed to check if %s exists: %w", absProjectPath, err)
+	} else if !exist {
+		return "", fmt.Errorf("%s does not exist", absProjectPath)
+	}
+
+	resolvedProjectPath, err := filepath.EvalSymlinks(absProjectPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve symlinks of %s: %w", absProjectPath, err)
+	}
+
+	if ext := filepath.Ext(resolvedProjectPath); ext != ".xcodeproj" && ext != ".xcworkspace" {
+		return "", fmt.Errorf("should be and .xcodeproj or .xcworkspace path")
+	}
+
+	return resolvedProjectPath, nil
+}
+
+// projectArgs returns the xcodebuild arguments selecting the project or workspace. The path is a single argument,
+// the command factory does not run the command through a shell, so paths with spaces need no quoting.
+func projectArgs(projectPath string) []string {
+	if filepath.Ext(projectPath) == ".xcworkspace" {
+		return []string{"-workspace", projectPath}
+	}
+	return []string{"-project", projectPath}
+}
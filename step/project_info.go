@@ -0,0 +1,105 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// projectInfoBuildSettingKeys are the build settings printed for each target of the scheme.
+var projectInfoBuildSettingKeys = []string{"PRODUCT_BUNDLE_IDENTIFIER", "DEVELOPMENT_TEAM"}
+
+type targetBuildSettings struct {
+	Target        string            `json:"target"`
+	BuildSettings map[string]string `json:"buildSettings"`
+}
+
+// parseShowBuildSettings parses the output of `xcodebuild -showBuildSettings -json`.
+func parseShowBuildSettings(out string) ([]targetBuildSettings, error) {
+	// xcodebuild might print warnings (eg. "xcodebuild[1234:5678] warning: ...") before the JSON output
+	if !strings.HasPrefix(out, "[") {
+		if index := strings.Index(out, "\n["); index >= 0 {
+			out = out[index+1:]
+		}
+	}
+
+	var settings []targetBuildSettings
+	if err := json.Unmarshal([]byte(out), &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse xcodebuild -showBuildSettings output: %w", err)
+	}
+	return settings, nil
+}
+
+// PrintProjectInfoOpts ...
+type PrintProjectInfoOpts struct {
+	ProjectPath    string
+	Scheme         string
+	Configuration  string
+	XcodebuildPath string
+}
+
+// PrintProjectInfo prints the project's schemes, targets and configurations,
+// and the key build settings of the scheme's targets (if the scheme is resolved).
+func (s XcodebuildArchiver) PrintProjectInfo(opts PrintProjectInfoOpts) error {
+	s.logger.Println()
+	s.logger.Infof("Project info:")
+
+	out, err := s.listProject(opts.XcodebuildPath, opts.ProjectPath)
+	if err != nil {
+		return err
+	}
+	list, err := parseXcodebuildList(out)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Printf("Name: %s", list.Name)
+	s.printProjectInfoList("Schemes", list.Schemes)
+	s.printProjectInfoList("Targets", list.Targets)
+	s.printProjectInfoList("Configurations", list.Configurations)
+
+	if opts.Scheme == "" {
+		s.logger.Println()
+		s.logger.Warnf("No scheme resolved, skipping the build settings")
+		return nil
+	}
+
+	args := append([]string{"-showBuildSettings", "-json"}, projectArgs(opts.ProjectPath)...)
+	args = append(args, "-scheme", opts.Scheme)
+	if opts.Configuration != "" {
+		args = append(args, "-configuration", opts.Configuration)
+	}
+
+	cmd := s.cmdFactory.Create(opts.XcodebuildPath, args, nil)
+	s.logger.Println()
+	s.logger.Printf("$ %s", cmd.PrintableCommandArgs())
+	out, err = cmd.RunAndReturnTrimmedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to read the build settings of scheme (%s): %w", opts.Scheme, err)
+	}
+	settings, err := parseShowBuildSettings(out)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Printf("Build settings of scheme %s:", opts.Scheme)
+	for _, target := range settings {
+		s.logger.Printf("- %s", target.Target)
+		for _, key := range projectInfoBuildSettingKeys {
+			value := target.BuildSettings[key]
+			if value == "" {
+				value = "-"
+			}
+			s.logger.Printf("  %s: %s", key, value)
+		}
+	}
+
+	return nil
+}
+
+func (s XcodebuildArchiver) printProjectInfoList(title string, items []string) {
+	s.logger.Printf("%s:", title)
+	for _, item := range items {
+		s.logger.Printf("- %s", item)
+	}
+}
@@ -0,0 +1,58 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseXcodebuildList(t *testing.T) {
+	out := `{"project":{"configurations":["Debug","Release"],"name":"Sample","schemes":["Sample"],"targets":["Sample","SampleTests"]}}`
+
+	got, err := parseXcodebuildList(out)
+	require.NoError(t, err)
+	require.Equal(t, xcodebuildListContainer{
+		Name:           "Sample",
+		Schemes:        []string{"Sample"},
+		Targets:        []string{"Sample", "SampleTests"},
+		Configurations: []string{"Debug", "Release"},
+	}, got)
+}
+
+func Test_parseShowBuildSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    []targetBuildSettings
+		wantErr bool
+	}{
+		{
+			name: "multiple targets with leading warning",
+			out: `2023-01-01 12:00:00.000 xcodebuild[1234:5678] warning: some warning
+[
+  {"action":"build","buildSettings":{"DEVELOPMENT_TEAM":"72SA8V3WYL","PRODUCT_BUNDLE_IDENTIFIER":"io.bitrise.Sample"},"target":"Sample"},
+  {"action":"build","buildSettings":{"PRODUCT_BUNDLE_IDENTIFIER":"io.bitrise.Sample.widget"},"target":"Widget"}
+]`,
+			want: []targetBuildSettings{
+				{Target: "Sample", BuildSettings: map[string]string{"DEVELOPMENT_TEAM": "72SA8V3WYL", "PRODUCT_BUNDLE_IDENTIFIER": "io.bitrise.Sample"}},
+				{Target: "Widget", BuildSettings: map[string]string{"PRODUCT_BUNDLE_IDENTIFIER": "io.bitrise.Sample.widget"}},
+			},
+		},
+		{
+			name:    "invalid output",
+			out:     "xcodebuild: error: The project does not contain a scheme named Sample.",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseShowBuildSettings(tt.out)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-utils/v2/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXcodebuildArchiver_resolveProjectPath(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	workspacePath := filepath.Join(dir, "My Project", "My App.xcworkspace")
+	require.NoError(t, os.MkdirAll(workspacePath, 0755))
+	symlinkPath := filepath.Join(dir, "Linked App.xcworkspace")
+	require.NoError(t, os.Symlink(workspacePath, symlinkPath))
+	extensionlessSymlinkPath := filepath.Join(dir, "workspace")
+	require.NoError(t, os.Symlink(workspacePath, extensionlessSymlinkPath))
+	otherDir := filepath.Join(dir, "Sources")
+	require.NoError(t, os.MkdirAll(otherDir, 0755))
+
+	archiver := XcodebuildArchiver{
+		pathChecker:  pathutil.NewPathChecker(),
+		pathModifier: pathutil.NewPathModifier(),
+		logger:       log.NewLogger(),
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{name: "path with spaces", path: workspacePath, want: workspacePath},
+		{name: "symlinked workspace", path: symlinkPath, want: workspacePath},
+		{name: "symlink without extension", path: extensionlessSymlinkPath, want: workspacePath},
+		{name: "missing project", path: filepath.Join(dir, "Missing.xcodeproj"), wantErr: filepath.Join(dir, "Missing.xcodeproj") + " does not exist"},
+		{name: "not a project", path: otherDir, wantErr: "should be and .xcodeproj or .xcworkspace path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := archiver.resolveProjectPath(tt.path)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_projectArgs(t *testing.T) {
+	require.Equal(t, []string{"-workspace", "/src/My Project/My App.xcworkspace"}, projectArgs("/src/My Project/My App.xcworkspace"))
+	require.Equal(t, []string{"-project", "/src/My Project/My App.xcodeproj"}, projectArgs("/src/My Project/My App.xcodeproj"))
+}
@@ -0,0 +1,112 @@
+package step
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+)
+
+const (
+	bitriseResolvePackagesLogPthEnvKey = "BITRISE_RESOLVE_PACKAGE_DEPENDENCIES_LOG_PATH"
+	resolvePackagesLogFilename         = "resolve_package_dependencies.log"
+	resolvePackagesRetryDelay          = 10 * time.Second
+)
+
+// ResolvePackageDependenciesOpts ...
+type ResolvePackageDependenciesOpts struct {
+	ProjectPath                 string
+	Scheme                      string
+	ClonedSourcePackagesDirPath string
+	MaxAttempts                 int
+	OutputDir                   string
+	OverwriteOutputs            bool
+	XcodebuildPath              string
+
+	Configuration     string
+	AdditionalOptions []string
+}
+
+// ResolvePackageDependencies resolves the Swift Package dependencies as a separate phase before the archive, so a network blip
+// during resolution does not waste an archive attempt. The resolution is retried independently of the archive,
+// and its log is exported into the OutputDir.
+func (s XcodebuildArchiver) ResolvePackageDependencies(opts ResolvePackageDependenciesOpts) error {
+	s.logger.Println()
+	s.logger.Infof("Resolving Swift package dependencies")
+
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var (
+		resolveLog bytes.Buffer
+		resolveErr error
+	)
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			s.logger.Warnf("Resolving Swift package dependencies failed, retrying in %s: %s", resolvePackagesRetryDelay, resolveErr)
+			s.sleeper.Sleep(resolvePackagesRetryDelay)
+			s.logger.Infof("Resolve attempt %d of %d", attempt, maxAttempts)
+		}
+
+		fmt.Fprintf(&resolveLog, "# Attempt %d\n", attempt)
+		if resolveErr = s.runResolvePackageDependencies(opts, &resolveLog); resolveErr == nil {
+			break
+		}
+	}
+
+	outputPaths := outputPathResolver{overwrite: opts.OverwriteOutputs, logger: s.logger}
+	logPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, resolvePackagesLogFilename))
+	if err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", bitriseResolvePackagesLogPthEnvKey, err)
+	} else if err := ExportOutputFileContent(s.cmdFactory, resolveLog.String(), logPath, bitriseResolvePackagesLogPthEnvKey); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", bitriseResolvePackagesLogPthEnvKey, err)
+	} else {
+		s.logger.Donef("The package resolution log path is now available in the Environment Variable: %s (value: %s)", bitriseResolvePackagesLogPthEnvKey, logPath)
+	}
+
+	if resolveErr != nil {
+		return fmt.Errorf("failed to resolve Swift package dependencies after %d attempts: %w", maxAttempts, resolveErr)
+	}
+	return nil
+}
+
+func (s XcodebuildArchiver) runResolvePackageDependencies(opts ResolvePackageDependenciesOpts, log io.Writer) error {
+	args := projectArgs(opts.ProjectPath)
+	args = append(args, "-scheme", opts.Scheme)
+	if opts.Configuration != "" {
+		args = append(args, "-configuration", opts.Configuration)
+	}
+	args = append(args, "-resolvePackageDependencies")
+	args = append(args, opts.AdditionalOptions...)
+	args = append(args, clonedSourcePackagesDirArgs(opts.ClonedSourcePackagesDirPath)...)
+
+	xcodebuildPath := opts.XcodebuildPath
+	if xcodebuildPath == "" {
+		xcodebuildPath = defaultXcodebuildPath
+	}
+
+	outWriter := io.MultiWriter(log, os.Stdout)
+	cmd := s.cmdFactory.Create(xcodebuildPath, args, &command.Opts{
+		Stdout: outWriter,
+		Stderr: outWriter,
+	})
+
+	s.logger.TDonef("$ %s", cmd.PrintableCommandArgs())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", cmd.PrintableCommandArgs(), err)
+	}
+	return nil
+}
+
+func clonedSourcePackagesDirArgs(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	return []string{"-clonedSourcePackagesDirPath", dir}
+}
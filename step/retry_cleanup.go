@@ -0,0 +1,244 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+)
+
+// CleanupTier is the cleanup performed before an archive retry attempt.
+// Every tier includes the cleanup of the previous tiers.
+type CleanupTier string
+
+const (
+	// CleanupTierNone re-runs the archive without any cleanup.
+	CleanupTierNone CleanupTier = "none"
+	// CleanupTierClean runs `xcodebuild clean`.
+	CleanupTierClean CleanupTier = "clean"
+	// CleanupTierDerivedData additionally wipes DerivedData and the build state cache, and disables the Swift Package cache.
+	CleanupTierDerivedData CleanupTier = "derived_data"
+	// CleanupTierGlobalCaches additionally wipes Xcode's and Swift Package Manager's global caches.
+	CleanupTierGlobalCaches CleanupTier = "global_caches"
+)
+
+var cleanupTierLevels = map[CleanupTier]int{
+	CleanupTierNone:         0,
+	CleanupTierClean:        1,
+	CleanupTierDerivedData:  2,
+	CleanupTierGlobalCaches: 3,
+}
+
+func (t CleanupTier) includes(other CleanupTier) bool {
+	return cleanupTierLevels[t] >= cleanupTierLevels[other]
+}
+
+// RetryCleanupPlan is the escalation table of the retry cleanup: the first tier runs before the second attempt,
+// the second tier before the third attempt and so on. Attempts exceeding the table use its last tier,
+// and the final attempt always uses the last tier.
+type RetryCleanupPlan []CleanupTier
+
+// DefaultRetryCleanupPlan ...
+var DefaultRetryCleanupPlan = RetryCleanupPlan{CleanupTierNone, CleanupTierClean, CleanupTierDerivedData, CleanupTierGlobalCaches}
+
+func (p RetryCleanupPlan) String() string {
+	tiers := make([]string, 0, len(p))
+	for _, tier := range p {
+		tiers = append(tiers, string(tier))
+	}
+	return strings.Join(tiers, ",")
+}
+
+// ParseRetryCleanupPlan parses a comma or newline separated list of cleanup tiers.
+func ParseRetryCleanupPlan(s string) (RetryCleanupPlan, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+
+	var plan RetryCleanupPlan
+	for _, field := range fields {
+		tier := CleanupTier(strings.TrimSpace(field))
+		if tier == "" {
+			continue
+		}
+		if _, ok := cleanupTierLevels[tier]; !ok {
+			return nil, fmt.Errorf("unknown cleanup tier: %s", tier)
+		}
+		plan = append(plan, tier)
+	}
+
+	if len(plan) == 0 {
+		return DefaultRetryCleanupPlan, nil
+	}
+	return plan, nil
+}
+
+// RetryCleanMode is the user facing switch of the retry cleanup.
+type RetryCleanMode string
+
+const (
+	// RetryCleanModeNone re-runs the archive without any cleanup.
+	RetryCleanModeNone RetryCleanMode = "none"
+	// RetryCleanModeClean runs `xcodebuild clean` before every retry, leaving DerivedData and the global caches untouched.
+	RetryCleanModeClean RetryCleanMode = "clean"
+	// RetryCleanModeDerivedData additionally wipes DerivedData before every retry, leaving the global caches untouched.
+	RetryCleanModeDerivedData RetryCleanMode = "deriveddata"
+	// RetryCleanModeFull escalates the cleanup along the retry cleanup tiers, up to wiping the global caches.
+	RetryCleanModeFull RetryCleanMode = "full"
+)
+
+// RetryCleanupPlanForMode returns the cleanup plan of the given mode. Tiers is only used by RetryCleanModeFull,
+// customised tiers are rejected with the other modes, as they would be silently ignored.
+func RetryCleanupPlanForMode(mode RetryCleanMode, tiers RetryCleanupPlan) (RetryCleanupPlan, error) {
+	if mode != RetryCleanModeFull && tiers.String() != DefaultRetryCleanupPlan.String() {
+		return nil, fmt.Errorf("retry cleanup tiers (%s) are only used by the %s retry clean mode, but the mode is %s", tiers, RetryCleanModeFull, mode)
+	}
+
+	switch mode {
+	case RetryCleanModeNone:
+		return RetryCleanupPlan{CleanupTierNone}, nil
+	case RetryCleanModeClean:
+		return RetryCleanupPlan{CleanupTierClean}, nil
+	case RetryCleanModeDerivedData:
+		return RetryCleanupPlan{CleanupTierDerivedData}, nil
+	case RetryCleanModeFull:
+		return tiers, nil
+	default:
+		return nil, fmt.Errorf("unknown retry clean mode: %s", mode)
+	}
+}
+
+// TierForAttempt returns the cleanup tier to run before the given (retry) attempt.
+func (p RetryCleanupPlan) TierForAttempt(attempt, maxAttempts int) CleanupTier {
+	if attempt < 2 || len(p) == 0 {
+		return CleanupTierNone
+	}
+
+	last := p[len(p)-1]
+	if attempt == maxAttempts && attempt > 2 {
+		return last
+	}
+
+	index := attempt - 2
+	if index >= len(p) {
+		return last
+	}
+	return p[index]
+}
+
+// AttemptCleanup is the effective clean behavior of an archive attempt.
+type AttemptCleanup struct {
+	// CleanAction adds xcodebuild's clean action to the archive command
+	CleanAction bool
+	// Tier is the retry cleanup run before the attempt
+	Tier CleanupTier
+}
+
+// CleanupForAttempt returns the clean behavior of the given attempt. PerformCleanAction only applies to the first attempt,
+// the retry attempts are cleaned by the retry cleanup tiers, so that an attempt is never cleaned twice.
+func (p RetryCleanupPlan) CleanupForAttempt(attempt, maxAttempts int, performCleanAction bool) AttemptCleanup {
+	if attempt < 2 {
+		return AttemptCleanup{CleanAction: performCleanAction, Tier: CleanupTierNone}
+	}
+	return AttemptCleanup{Tier: p.TierForAttempt(attempt, maxAttempts)}
+}
+
+// Description ...
+func (c AttemptCleanup) Description() string {
+	switch {
+	case c.CleanAction:
+		return "clean action of the archive command (PerformCleanAction)"
+	case c.Tier.includes(CleanupTierClean):
+		return fmt.Sprintf("xcodebuild clean of the retry cleanup tier (%s)", c.Tier)
+	default:
+		return "no clean"
+	}
+}
+
+// RetryCleanupOpts ...
+type RetryCleanupOpts struct {
+	ProjectPath string
+	Scheme      string
+	Tier        CleanupTier
+
+	XcodebuildPath string
+
+	// PreserveDerivedData skips wiping DerivedData, so that the retry can build incrementally
+	PreserveDerivedData bool
+}
+
+// RetryCleanupResult ...
+type RetryCleanupResult struct {
+	DisableCache bool
+}
+
+// CleanForRetry runs the given cleanup tier before an archive retry attempt. Failures are logged, but do not stop the retry.
+func (s XcodebuildArchiver) CleanForRetry(opts RetryCleanupOpts) RetryCleanupResult {
+	s.logger.Infof("Running retry cleanup tier: %s", opts.Tier)
+
+	var result RetryCleanupResult
+	if opts.Tier == CleanupTierNone {
+		s.logger.Printf("No cleanup, re-running the archive")
+		return result
+	}
+
+	if opts.Tier.includes(CleanupTierClean) {
+		cleanArgs := append([]string{"clean"}, projectArgs(opts.ProjectPath)...)
+		cleanArgs = append(cleanArgs, "-scheme", opts.Scheme)
+
+		xcodebuildPath := opts.XcodebuildPath
+		if xcodebuildPath == "" {
+			xcodebuildPath = defaultXcodebuildPath
+		}
+		s.runCleanupCommand("Performing clean", xcodebuildPath, cleanArgs...)
+	}
+
+	if opts.Tier.includes(CleanupTierDerivedData) && opts.PreserveDerivedData {
+		s.logger.Warnf("DerivedData is preserved for the retry (RetryPreservesDerivedData), the archive reuses the previous attempt's build state.")
+		s.logger.Warnf("If the archive keeps failing with cache related errors, disable RetryPreservesDerivedData.")
+	} else if opts.Tier.includes(CleanupTierDerivedData) {
+		home := os.Getenv("HOME")
+		s.removeDirContents("derived data", defaultDerivedDataDir())
+		s.removeDirContents("build state cache", filepath.Join(home, "Library/Developer/Xcode/BuildState"))
+		result.DisableCache = true
+	}
+
+	if opts.Tier.includes(CleanupTierGlobalCaches) {
+		home := os.Getenv("HOME")
+		s.removeDirContents("Xcode cache", filepath.Join(home, "Library/Caches/com.apple.dt.Xcode"))
+		s.removeDirContents("Swift Package Manager cache", filepath.Join(home, "Library/Caches/org.swift.swiftpm"))
+	}
+
+	return result
+}
+
+func (s XcodebuildArchiver) runCleanupCommand(description, name string, args ...string) {
+	cmd := s.cmdFactory.Create(name, args, &command.Opts{})
+	s.logger.Printf("%s: %s", description, cmd.PrintableCommandArgs())
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		s.logger.Warnf("%s failed: %s", description, err)
+		if out != "" {
+			s.logger.Warnf("Command output: %s", out)
+		}
+	}
+}
+
+func (s XcodebuildArchiver) removeDirContents(description, dir string) {
+	s.logger.Printf("Cleaning %s: %s", description, dir)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.Warnf("Failed to clear %s: %s", description, err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			s.logger.Warnf("Failed to clear %s: %s", description, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryCleanupPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    RetryCleanupPlan
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: DefaultRetryCleanupPlan},
+		{name: "comma separated", input: "clean, global_caches", want: RetryCleanupPlan{CleanupTierClean, CleanupTierGlobalCaches}},
+		{name: "newline separated", input: "none\nderived_data\n", want: RetryCleanupPlan{CleanupTierNone, CleanupTierDerivedData}},
+		{name: "unknown tier", input: "clean,everything", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRetryCleanupPlan(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRetryCleanupPlan_TierForAttempt(t *testing.T) {
+	tests := []struct {
+		name        string
+		attempt     int
+		maxAttempts int
+		want        CleanupTier
+	}{
+		{name: "first attempt", attempt: 1, maxAttempts: 5, want: CleanupTierNone},
+		{name: "second attempt", attempt: 2, maxAttempts: 5, want: CleanupTierNone},
+		{name: "third attempt", attempt: 3, maxAttempts: 5, want: CleanupTierClean},
+		{name: "fourth attempt", attempt: 4, maxAttempts: 5, want: CleanupTierDerivedData},
+		{name: "final attempt", attempt: 5, maxAttempts: 5, want: CleanupTierGlobalCaches},
+		{name: "early final attempt", attempt: 3, maxAttempts: 3, want: CleanupTierGlobalCaches},
+		{name: "second attempt is final", attempt: 2, maxAttempts: 2, want: CleanupTierNone},
+		{name: "attempt exceeding the table", attempt: 7, maxAttempts: 8, want: CleanupTierGlobalCaches},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, DefaultRetryCleanupPlan.TierForAttempt(tt.attempt, tt.maxAttempts))
+		})
+	}
+}
+
+func TestRetryCleanupPlan_CleanupForAttempt(t *testing.T) {
+	require.Equal(t, AttemptCleanup{CleanAction: true, Tier: CleanupTierNone}, DefaultRetryCleanupPlan.CleanupForAttempt(1, 3, true))
+	require.Equal(t, AttemptCleanup{CleanAction: false, Tier: CleanupTierNone}, DefaultRetryCleanupPlan.CleanupForAttempt(1, 3, false))
+	require.Equal(t, AttemptCleanup{CleanAction: false, Tier: CleanupTierNone}, DefaultRetryCleanupPlan.CleanupForAttempt(2, 3, true))
+	require.Equal(t, AttemptCleanup{CleanAction: false, Tier: CleanupTierGlobalCaches}, DefaultRetryCleanupPlan.CleanupForAttempt(3, 3, true))
+}
+
+func TestRetryCleanupPlanForMode(t *testing.T) {
+	const clean = "xcodebuild clean -project Sample.xcodeproj -scheme Sample"
+
+	tests := []struct {
+		mode                 RetryCleanMode
+		wantCommands         []string
+		wantDerivedDataWiped bool
+		wantCachesWiped      bool
+	}{
+		{mode: RetryCleanModeNone, wantCommands: nil},
+		{mode: RetryCleanModeClean, wantCommands: []string{clean, clean, clean}},
+		{mode: RetryCleanModeDerivedData, wantCommands: []string{clean, clean, clean}, wantDerivedDataWiped: true},
+		{mode: RetryCleanModeFull, wantCommands: []string{clean, clean}, wantDerivedDataWiped: true, wantCachesWiped: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			derivedDataFile := filepath.Join(home, "Library/Developer/Xcode/DerivedData/Sample-abc/info.plist")
+			cacheFile := filepath.Join(home, "Library/Caches/org.swift.swiftpm/repositories/package")
+			for _, pth := range []string{derivedDataFile, cacheFile} {
+				require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+				require.NoError(t, os.WriteFile(pth, nil, 0644))
+			}
+
+			plan, err := RetryCleanupPlanForMode(tt.mode, DefaultRetryCleanupPlan)
+			require.NoError(t, err)
+
+			factory := &recordingCommandFactory{}
+			archiver := XcodebuildArchiver{cmdFactory: factory, logger: log.NewLogger()}
+
+			const maxAttempts = 4
+			for attempt := 2; attempt <= maxAttempts; attempt++ {
+				archiver.CleanForRetry(RetryCleanupOpts{
+					ProjectPath: "Sample.xcodeproj",
+					Scheme:      "Sample",
+					Tier:        plan.CleanupForAttempt(attempt, maxAttempts, false).Tier,
+				})
+			}
+
+			require.Equal(t, tt.wantCommands, factory.commands)
+			require.Equal(t, tt.wantDerivedDataWiped, !fileExists(t, derivedDataFile))
+			require.Equal(t, tt.wantCachesWiped, !fileExists(t, cacheFile))
+		})
+	}
+
+	_, err := RetryCleanupPlanForMode("everything", DefaultRetryCleanupPlan)
+	require.Error(t, err)
+
+	customTiers := RetryCleanupPlan{CleanupTierDerivedData}
+	plan, err := RetryCleanupPlanForMode(RetryCleanModeFull, customTiers)
+	require.NoError(t, err)
+	require.Equal(t, customTiers, plan)
+
+	_, err = RetryCleanupPlanForMode(RetryCleanModeClean, customTiers)
+	require.EqualError(t, err, "retry cleanup tiers (derived_data) are only used by the full retry clean mode, but the mode is clean")
+}
+
+func fileExists(t *testing.T, pth string) bool {
+	_, err := os.Stat(pth)
+	if os.IsNotExist(err) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
@@ -0,0 +1,80 @@
+package step
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+// RetryPolicy overrides the number of archive retries for failures whose log matches the pattern.
+type RetryPolicy struct {
+	Pattern    *regexp.Regexp
+	RetryCount int
+}
+
+// RetryPolicies are evaluated in order, the first matching policy wins.
+type RetryPolicies []RetryPolicy
+
+// ParseRetryPolicies parses a newline separated list of `pattern=count` policies, where pattern is a regular expression
+// matched against the failed attempt's log, and count is the number of retries allowed for the matching failures.
+// Counts allowing more attempts than the attempts limit are clamped to the limit, like the maximum retry count.
+func ParseRetryPolicies(s string, attemptsLimit int, logger log.Logger) (RetryPolicies, error) {
+	var policies RetryPolicies
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		separatorIndex := strings.LastIndex(line, "=")
+		if separatorIndex < 1 {
+			return nil, fmt.Errorf("invalid retry policy (%s): should be in pattern=count format", line)
+		}
+
+		pattern, countStr := line[:separatorIndex], strings.TrimSpace(line[separatorIndex+1:])
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry policy pattern (%s): %w", pattern, err)
+		}
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid retry policy count (%s): should be a non-negative number", countStr)
+		}
+		if count+1 > attemptsLimit {
+			logger.Warnf("Retry policy (%s) count (%d) exceeds the maximum archive retry count limit (%d attempts), using %d", pattern, count, attemptsLimit, attemptsLimit-1)
+			count = attemptsLimit - 1
+		}
+
+		policies = append(policies, RetryPolicy{Pattern: re, RetryCount: count})
+	}
+	return policies, nil
+}
+
+// Match returns the first policy matching the failed attempt's log.
+func (p RetryPolicies) Match(log string) (RetryPolicy, bool) {
+	for _, policy := range p {
+		if policy.Pattern.MatchString(log) {
+			return policy, true
+		}
+	}
+	return RetryPolicy{}, false
+}
+
+// capMaxRetryCount validates the maximum number of archive attempts: negative values are rejected, 0 means a single attempt,
+// and values above the (positive) limit are clamped to the limit, so that a typo does not keep retrying a broken build for hours.
+func capMaxRetryCount(count, limit int, logger log.Logger) (int, error) {
+	if count < 0 {
+		return 0, fmt.Errorf("should not be negative")
+	}
+	if count == 0 {
+		count = 1
+	}
+	if count > limit {
+		logger.Warnf("Maximum archive retry count (%d) exceeds the limit (%d), using %d", count, limit, limit)
+		count = limit
+	}
+	return count, nil
+}
@@ -0,0 +1,87 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryPolicies(t *testing.T) {
+	policies, err := ParseRetryPolicies("", 10, log.NewLogger())
+	require.NoError(t, err)
+	require.Empty(t, policies)
+
+	policies, err = ParseRetryPolicies("Could not resolve package dependencies=3\n\n  Code ?[Ss]igning=0  \nkey=value=1", 10, log.NewLogger())
+	require.NoError(t, err)
+	require.Len(t, policies, 3)
+	require.Equal(t, "Could not resolve package dependencies", policies[0].Pattern.String())
+	require.Equal(t, 3, policies[0].RetryCount)
+	require.Equal(t, "Code ?[Ss]igning", policies[1].Pattern.String())
+	require.Equal(t, 0, policies[1].RetryCount)
+	require.Equal(t, "key=value", policies[2].Pattern.String())
+
+	for _, invalid := range []string{"no count", "=1", "pattern=-1", "pattern=many", "[=1"} {
+		_, err := ParseRetryPolicies(invalid, 10, log.NewLogger())
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestParseRetryPolicies_cappedByTheLimit(t *testing.T) {
+	policies, err := ParseRetryPolicies("Could not resolve package dependencies=100\nCode ?[Ss]igning=9\nerror:=1", 10, log.NewLogger())
+	require.NoError(t, err)
+	require.Equal(t, 9, policies[0].RetryCount)
+	require.Equal(t, 9, policies[1].RetryCount)
+	require.Equal(t, 1, policies[2].RetryCount)
+}
+
+func TestRetryPolicies_Match(t *testing.T) {
+	policies, err := ParseRetryPolicies("Could not resolve package dependencies=3\nCode ?[Ss]igning=0\nerror:=1", 10, log.NewLogger())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		log            string
+		wantRetryCount int
+		wantMatch      bool
+	}{
+		{name: "network failure", log: "xcodebuild: error: Could not resolve package dependencies:", wantRetryCount: 3, wantMatch: true},
+		{name: "code signing failure", log: "error: No signing certificate found (in target 'App'). Code Signing Error", wantRetryCount: 0, wantMatch: true},
+		{name: "first matching policy wins", log: "error: Code Signing: Could not resolve package dependencies", wantRetryCount: 3, wantMatch: true},
+		{name: "other error", log: "error: cannot find 'Foo' in scope", wantRetryCount: 1, wantMatch: true},
+		{name: "no policy matches", log: "** ARCHIVE FAILED **", wantMatch: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, ok := policies.Match(tt.log)
+			require.Equal(t, tt.wantMatch, ok)
+			require.Equal(t, tt.wantRetryCount, policy.RetryCount)
+		})
+	}
+}
+
+func Test_capMaxRetryCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		limit   int
+		want    int
+		wantErr string
+	}{
+		{name: "within the limit", count: 3, limit: 10, want: 3},
+		{name: "zero means a single attempt", count: 0, limit: 10, want: 1},
+		{name: "clamped to the limit", count: 100, limit: 10, want: 10},
+		{name: "negative count", count: -1, limit: 10, wantErr: "should not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := capMaxRetryCount(tt.count, tt.limit, log.NewLogger())
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcworkspace"
+)
+
+type xcodebuildListContainer struct {
+	Name           string   `json:"name"`
+	Schemes        []string `json:"schemes"`
+	Targets        []string `json:"targets"`
+	Configurations []string `json:"configurations"`
+}
+
+type xcodebuildListOutput struct {
+	Project   *xcodebuildListContainer `json:"project"`
+	Workspace *xcodebuildListContainer `json:"workspace"`
+}
+
+// parseXcodebuildList parses the output of `xcodebuild -list -json`, returning the listed workspace or project.
+func parseXcodebuildList(out string) (xcodebuildListContainer, error) {
+	// xcodebuild might print warnings before the JSON output
+	if index := strings.Index(out, "{"); index > 0 {
+		out = out[index:]
+	}
+
+	var list xcodebuildListOutput
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return xcodebuildListContainer{}, fmt.Errorf("failed to parse xcodebuild -list output: %w", err)
+	}
+
+	switch {
+	case list.Workspace != nil:
+		return *list.Workspace, nil
+	case list.Project != nil:
+		return *list.Project, nil
+	default:
+		return xcodebuildListContainer{}, fmt.Errorf("no project or workspace found in xcodebuild -list output")
+	}
+}
+
+// parseXcodebuildListSchemes parses the schemes from the output of `xcodebuild -list -json`.
+func parseXcodebuildListSchemes(out string) ([]string, error) {
+	list, err := parseXcodebuildList(out)
+	if err != nil {
+		return nil, err
+	}
+	return list.Schemes, nil
+}
+
+// selectScheme validates that the configured scheme is listed and shared.
+// If no scheme is configured, the only shared scheme is selected.
+func selectScheme(configured string, schemes []string, shared map[string]bool) (string, error) {
+	var sharedSchemes []string
+	for _, scheme := range schemes {
+		if shared[scheme] {
+			sharedSchemes = append(sharedSchemes, scheme)
+		}
+	}
+	sort.Strings(sharedSchemes)
+	available := strings.Join(sharedSchemes, ", ")
+
+	if configured == "" {
+		switch len(sharedSchemes) {
+		case 0:
+			return "", fmt.Errorf("no scheme provided and the project has no shared schemes, mark the scheme as Shared in Xcode's Manage Schemes")
+		case 1:
+			return sharedSchemes[0], nil
+		default:
+			return "", fmt.Errorf("no scheme provided and the project has multiple shared schemes, available shared schemes: %s", available)
+		}
+	}
+
+	found := false
+	for _, scheme := range schemes {
+		if scheme == configured {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("scheme (%s) not found in the project, available shared schemes: %s", configured, available)
+	}
+	if !shared[configured] {
+		return "", fmt.Errorf("scheme (%s) is not shared, mark it as Shared in Xcode's Manage Schemes and commit it, available shared schemes: %s", configured, available)
+	}
+	return configured, nil
+}
+
+// sharedSchemeNames returns the names of the schemes in the xcshareddata of the project,
+// or in case of a workspace, of the workspace and its projects.
+func sharedSchemeNames(projectPath string) (map[string]bool, error) {
+	containers := []string{projectPath}
+	if xcworkspace.IsWorkspace(projectPath) {
+		workspace, err := xcworkspace.Open(projectPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open workspace: %w", err)
+		}
+		projectPaths, err := workspace.ProjectFileLocations()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the workspace's projects: %w", err)
+		}
+		containers = append(containers, projectPaths...)
+	}
+
+	shared := map[string]bool{}
+	for _, container := range containers {
+		schemePaths, err := filepath.Glob(filepath.Join(container, "xcshareddata", "xcschemes", "*.xcscheme"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for shared schemes: %w", err)
+		}
+		for _, schemePath := range schemePaths {
+			shared[strings.TrimSuffix(filepath.Base(schemePath), ".xcscheme")] = true
+		}
+	}
+	return shared, nil
+}
+
+// listProject runs `xcodebuild -list -json` on the project.
+func (s XcodebuildArchiver) listProject(xcodebuildPath, projectPath string) (string, error) {
+	args := append([]string{"-list", "-json"}, projectArgs(projectPath)...)
+
+	cmd := s.cmdFactory.Create(xcodebuildPath, args, nil)
+	s.logger.Printf("$ %s", cmd.PrintableCommandArgs())
+	out, err := cmd.RunAndReturnTrimmedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to list the project's schemes: %w", err)
+	}
+	return out, nil
+}
+
+// resolveScheme lists the project's schemes with xcodebuild, and validates (or selects) the scheme to archive.
+func (s XcodebuildArchiver) resolveScheme(xcodebuildPath, projectPath, scheme string) (string, error) {
+	out, err := s.listProject(xcodebuildPath, projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	schemes, err := parseXcodebuildListSchemes(out)
+	if err != nil {
+		return "", err
+	}
+
+	shared, err := sharedSchemeNames(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	return selectScheme(scheme, schemes, shared)
+}
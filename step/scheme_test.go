@@ -0,0 +1,128 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseXcodebuildListSchemes(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "project",
+			out:  `{"project":{"configurations":["Debug","Release"],"name":"Sample","schemes":["Sample","SampleTests"],"targets":["Sample"]}}`,
+			want: []string{"Sample", "SampleTests"},
+		},
+		{
+			name: "workspace with leading warning",
+			out: `2023-01-01 12:00:00.000 xcodebuild[1234:5678] warning: some warning
+{"workspace":{"name":"Sample","schemes":["Pods-Sample","Sample"]}}`,
+			want: []string{"Pods-Sample", "Sample"},
+		},
+		{
+			name:    "invalid output",
+			out:     "xcodebuild: error: invalid project",
+			wantErr: true,
+		},
+		{
+			name:    "no container",
+			out:     `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseXcodebuildListSchemes(tt.out)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_selectScheme(t *testing.T) {
+	schemes := []string{"Sample", "SampleTests", "Local"}
+	shared := map[string]bool{"Sample": true, "SampleTests": true}
+
+	tests := []struct {
+		name       string
+		configured string
+		schemes    []string
+		shared     map[string]bool
+		want       string
+		wantErr    string
+	}{
+		{
+			name:       "configured shared scheme",
+			configured: "Sample",
+			schemes:    schemes,
+			shared:     shared,
+			want:       "Sample",
+		},
+		{
+			name:       "configured scheme not found",
+			configured: "Missing",
+			schemes:    schemes,
+			shared:     shared,
+			wantErr:    "scheme (Missing) not found in the project, available shared schemes: Sample, SampleTests",
+		},
+		{
+			name:       "configured scheme not shared",
+			configured: "Local",
+			schemes:    schemes,
+			shared:     shared,
+			wantErr:    "scheme (Local) is not shared, mark it as Shared in Xcode's Manage Schemes and commit it, available shared schemes: Sample, SampleTests",
+		},
+		{
+			name:    "auto-selects the only shared scheme",
+			schemes: schemes,
+			shared:  map[string]bool{"Sample": true},
+			want:    "Sample",
+		},
+		{
+			name:    "no scheme and multiple shared schemes",
+			schemes: schemes,
+			shared:  shared,
+			wantErr: "no scheme provided and the project has multiple shared schemes, available shared schemes: Sample, SampleTests",
+		},
+		{
+			name:    "no scheme and no shared schemes",
+			schemes: schemes,
+			shared:  map[string]bool{},
+			wantErr: "no scheme provided and the project has no shared schemes, mark the scheme as Shared in Xcode's Manage Schemes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectScheme(tt.configured, tt.schemes, tt.shared)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_sharedSchemeNames(t *testing.T) {
+	projectPath := filepath.Join(t.TempDir(), "Sample.xcodeproj")
+	schemesDir := filepath.Join(projectPath, "xcshareddata", "xcschemes")
+	require.NoError(t, os.MkdirAll(schemesDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(schemesDir, "Sample.xcscheme"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(schemesDir, "Sample Tests.xcscheme"), nil, 0644))
+
+	got, err := sharedSchemeNames(projectPath)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"Sample": true, "Sample Tests": true}, got)
+}
@@ -0,0 +1,134 @@
+package step
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"github.com/bitrise-io/go-xcode/v2/exportoptionsgenerator"
+	"github.com/bitrise-io/go-xcode/xcarchive"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+)
+
+type validateExportMethodBeforeArchiveOpts struct {
+	ExportMethod      string
+	ProjectPath       string
+	Scheme            string
+	Configuration     string
+	Platform          Platform // empty if detected from the project
+	AdditionalOptions []string
+	XcodebuildPath    string
+}
+
+// validateExportMethodBeforeArchive checks the selected export method against the provisioning profile set in the main target's
+// build settings, so that an incompatible configuration fails before the archive. It is called after the code signing assets
+// are prepared, as automatic code signing updates the project's profile. It returns false if the profile could not be determined,
+// in which case the archive's profile is validated after the archive.
+func (s XcodebuildArchiver) validateExportMethodBeforeArchive(opts validateExportMethodBeforeArchiveOpts) (bool, error) {
+	if opts.ExportMethod == "auto-detect" {
+		return true, nil
+	}
+
+	method, err := exportoptions.ParseMethod(opts.ExportMethod)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse export method: %s", err)
+	}
+
+	xcodeProj, scheme, configuration, err := OpenArchivableProject(opts.ProjectPath, opts.Scheme, opts.Configuration)
+	if err != nil {
+		s.logger.Warnf("Failed to open project, validating the export method after the archive: %s", err)
+		return false, nil
+	}
+	mainTarget, err := exportoptionsgenerator.ArchivableApplicationTarget(xcodeProj, scheme)
+	if err != nil {
+		s.logger.Warnf("Failed to read main application target, validating the export method after the archive: %s", err)
+		return false, nil
+	}
+	settings, err := XcodeBuild{XcodebuildPath: opts.XcodebuildPath, CmdFactory: s.cmdFactory, Logger: s.logger}.TargetBuildSettings(xcodeProj, mainTarget.Name, configuration, opts.AdditionalOptions...)
+	if err != nil {
+		s.logger.Warnf("Failed to read the build settings of target (%s), validating the export method after the archive: %s", mainTarget.Name, err)
+		return false, nil
+	}
+
+	profileType := profileutil.ProfileTypeIos
+	if opts.Platform == osX {
+		profileType = profileutil.ProfileTypeMacOs
+	}
+	installedProfiles, err := profileutil.InstalledProvisioningProfileInfos(profileType)
+	if err != nil {
+		s.logger.Warnf("Failed to list the installed provisioning profiles, validating the export method after the archive: %s", err)
+		return false, nil
+	}
+
+	profile, found, xcodeManaged := targetProvisioningProfile(settings, installedProfiles)
+	if xcodeManaged {
+		s.logger.Printf("Target (%s) uses Xcode managed code signing, skipping export method validation", mainTarget.Name)
+		return true, nil
+	}
+	if !found {
+		s.logger.Printf("The provisioning profile of target (%s) is not installed, validating the export method after the archive", mainTarget.Name)
+		return false, nil
+	}
+
+	s.logger.Printf("Validating export method (%s) against the provisioning profile of target (%s): %s (%s)", method, mainTarget.Name, profile.Name, profile.ExportType)
+
+	return true, validateExportMethodForProfile(method, profile)
+}
+
+// targetProvisioningProfile looks up the installed provisioning profile selected by the target's
+// PROVISIONING_PROFILE (UUID) or PROVISIONING_PROFILE_SPECIFIER (name or UUID) build setting.
+func targetProvisioningProfile(settings serialized.Object, installedProfiles []profileutil.ProvisioningProfileInfoModel) (profileutil.ProvisioningProfileInfoModel, bool, bool) {
+	if codeSignStyle, _ := settings.String("CODE_SIGN_STYLE"); codeSignStyle == "Automatic" {
+		return profileutil.ProvisioningProfileInfoModel{}, false, true
+	}
+
+	uuid, _ := settings.String("PROVISIONING_PROFILE")
+	specifier, _ := settings.String("PROVISIONING_PROFILE_SPECIFIER")
+	if uuid == "" && specifier == "" {
+		return profileutil.ProvisioningProfileInfoModel{}, false, false
+	}
+
+	for _, profile := range installedProfiles {
+		if (uuid != "" && profile.UUID == uuid) || (specifier != "" && (profile.Name == specifier || profile.UUID == specifier)) {
+			return profile, true, profile.IsXcodeManaged()
+		}
+	}
+	return profileutil.ProvisioningProfileInfoModel{}, false, false
+}
+
+// validateExportMethodForArchive checks if the provisioning profile used for signing the archive's main application
+// is compatible with the selected export method, so that an incompatible configuration fails before running -exportArchive.
+// It is used if the profile could not be determined before the archive.
+func (s XcodebuildArchiver) validateExportMethodForArchive(exportMethod string, archive xcarchive.IosArchive) error {
+	if exportMethod == "auto-detect" {
+		return nil
+	}
+
+	profile := archive.Application.ProvisioningProfile
+	if archive.IsXcodeManaged() {
+		s.logger.Printf("Archive is signed with an Xcode managed profile (%s), skipping export method validation", profile.Name)
+		return nil
+	}
+
+	method, err := exportoptions.ParseMethod(exportMethod)
+	if err != nil {
+		return fmt.Errorf("failed to parse export method: %s", err)
+	}
+
+	s.logger.Printf("Validating export method (%s) against the archive's provisioning profile: %s (%s)", method, profile.Name, profile.ExportType)
+
+	return validateExportMethodForProfile(method, profile)
+}
+
+func validateExportMethodForProfile(exportMethod exportoptions.Method, profile profileutil.ProvisioningProfileInfoModel) error {
+	profileType := profile.ExportType
+	if profileType == "" {
+		return fmt.Errorf("failed to determine the type of the provisioning profile: %s (%s)", profile.Name, profile.UUID)
+	}
+
+	if profileType != exportMethod {
+		return fmt.Errorf("the selected distribution method (%s) is incompatible with the archive's %s provisioning profile: %s (%s)", exportMethod, profileType, profile.Name, profile.UUID)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package step
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateExportMethodForProfile(t *testing.T) {
+	methods := []exportoptions.Method{
+		exportoptions.MethodDevelopment,
+		exportoptions.MethodAdHoc,
+		exportoptions.MethodEnterprise,
+		exportoptions.MethodAppStore,
+	}
+
+	for _, exportMethod := range methods {
+		for _, profileType := range methods {
+			t.Run(fmt.Sprintf("%s export with %s profile", exportMethod, profileType), func(t *testing.T) {
+				profile := profileutil.ProvisioningProfileInfoModel{
+					Name:       "Test Profile",
+					UUID:       "uuid",
+					ExportType: profileType,
+				}
+
+				err := validateExportMethodForProfile(exportMethod, profile)
+				if exportMethod == profileType {
+					require.NoError(t, err)
+				} else {
+					require.EqualError(t, err, fmt.Sprintf("the selected distribution method (%s) is incompatible with the archive's %s provisioning profile: Test Profile (uuid)", exportMethod, profileType))
+				}
+			})
+		}
+	}
+}
+
+func Test_validateExportMethodForProfile_unknownProfileType(t *testing.T) {
+	profile := profileutil.ProvisioningProfileInfoModel{Name: "Test Profile", UUID: "uuid"}
+
+	err := validateExportMethodForProfile(exportoptions.MethodAppStore, profile)
+	require.EqualError(t, err, "failed to determine the type of the provisioning profile: Test Profile (uuid)")
+}
+
+func Test_targetProvisioningProfile(t *testing.T) {
+	installedProfiles := []profileutil.ProvisioningProfileInfoModel{
+		{Name: "Development Profile", UUID: "dev-uuid", ExportType: exportoptions.MethodDevelopment},
+		{Name: "App Store Profile", UUID: "app-store-uuid", ExportType: exportoptions.MethodAppStore},
+		{Name: "iOS Team Provisioning Profile: *", UUID: "xcode-managed-uuid", ExportType: exportoptions.MethodDevelopment},
+	}
+
+	tests := []struct {
+		name             string
+		settings         serialized.Object
+		wantProfileUUID  string
+		wantFound        bool
+		wantXcodeManaged bool
+	}{
+		{
+			name:             "automatic code signing",
+			settings:         serialized.Object{"CODE_SIGN_STYLE": "Automatic", "PROVISIONING_PROFILE_SPECIFIER": "App Store Profile"},
+			wantXcodeManaged: true,
+		},
+		{
+			name:            "profile selected by UUID",
+			settings:        serialized.Object{"CODE_SIGN_STYLE": "Manual", "PROVISIONING_PROFILE": "app-store-uuid"},
+			wantProfileUUID: "app-store-uuid",
+			wantFound:       true,
+		},
+		{
+			name:            "profile selected by name",
+			settings:        serialized.Object{"CODE_SIGN_STYLE": "Manual", "PROVISIONING_PROFILE_SPECIFIER": "Development Profile"},
+			wantProfileUUID: "dev-uuid",
+			wantFound:       true,
+		},
+		{
+			name:            "profile specifier is a UUID",
+			settings:        serialized.Object{"PROVISIONING_PROFILE_SPECIFIER": "dev-uuid"},
+			wantProfileUUID: "dev-uuid",
+			wantFound:       true,
+		},
+		{
+			name:             "Xcode managed profile",
+			settings:         serialized.Object{"CODE_SIGN_STYLE": "Manual", "PROVISIONING_PROFILE": "xcode-managed-uuid"},
+			wantProfileUUID:  "xcode-managed-uuid",
+			wantFound:        true,
+			wantXcodeManaged: true,
+		},
+		{
+			name:     "profile not installed",
+			settings: serialized.Object{"CODE_SIGN_STYLE": "Manual", "PROVISIONING_PROFILE_SPECIFIER": "Ad Hoc Profile"},
+		},
+		{
+			name:     "no profile set",
+			settings: serialized.Object{"CODE_SIGN_STYLE": "Manual"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, found, xcodeManaged := targetProvisioningProfile(tt.settings, installedProfiles)
+			require.Equal(t, tt.wantProfileUUID, profile.UUID)
+			require.Equal(t, tt.wantFound, found)
+			require.Equal(t, tt.wantXcodeManaged, xcodeManaged)
+		})
+	}
+}
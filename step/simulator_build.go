@@ -0,0 +1,220 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/stringutil"
+	"github.com/bitrise-io/go-xcode/v2/xcconfig"
+	"github.com/bitrise-io/go-xcode/xcodebuild"
+)
+
+const bitriseSimulatorAppZipPthEnvKey = "BITRISE_SIMULATOR_APP_ZIP_PATH"
+
+type simulator struct {
+	sdk                 string
+	destinationPlatform string
+}
+
+// simulators maps the platforms to their Simulator SDKs and generic destination platforms.
+var simulators = map[Platform]simulator{
+	iOS:      {sdk: "iphonesimulator", destinationPlatform: "iOS Simulator"},
+	tvOS:     {sdk: "appletvsimulator", destinationPlatform: "tvOS Simulator"},
+	watchOS:  {sdk: "watchsimulator", destinationPlatform: "watchOS Simulator"},
+	visionOS: {sdk: "xrsimulator", destinationPlatform: "visionOS Simulator"},
+}
+
+// platformSimulator returns the Simulator of the platform.
+func platformSimulator(platform Platform) (simulator, error) {
+	sim, ok := simulators[platform]
+	if !ok {
+		return simulator{}, fmt.Errorf("%s apps can not be built for a Simulator", platform)
+	}
+	return sim, nil
+}
+
+// simulatorDestinationPlatform returns the platform of a generic Simulator destination (for example generic/platform=tvOS Simulator).
+func simulatorDestinationPlatform(destination string) (Platform, bool) {
+	for platform, sim := range simulators {
+		if destination == "generic/platform="+sim.destinationPlatform {
+			return platform, true
+		}
+	}
+	return "", false
+}
+
+type xcodeSimulatorBuildOpts struct {
+	ProjectPath   string
+	Scheme        string
+	Configuration string
+	LogFormatter  string
+
+	XcodebuildVerbosity         string
+	XcodebuildPath              string
+	BuildParallelism            int
+	XcodeMajorVersion           int
+	SkipPackagePluginValidation bool
+	SkipMacroValidation         bool
+	HeartbeatInterval           time.Duration
+	HangThreshold               time.Duration
+	HangDiagnosticsDir          string
+	OverwriteOutputs            bool
+	PerformCleanAction          bool
+	XcconfigContent             string
+	AdditionalOptions           []string
+	ClonedSourcePackagesDirPath string
+	Envs                        []string
+	Platform                    Platform // empty if detected from the project
+}
+
+type xcodeSimulatorBuildResult struct {
+	AppPath            string
+	XcodebuildBuildLog string
+}
+
+// xcodeSimulatorBuild builds the scheme for the Simulator of its platform (instead of archiving it for devices),
+// and locates the built app in the project's DerivedData. Code signing is disabled.
+func (s XcodebuildArchiver) xcodeSimulatorBuild(opts xcodeSimulatorBuildOpts) (xcodeSimulatorBuildResult, error) {
+	out := xcodeSimulatorBuildResult{}
+
+	platform := opts.Platform
+	if platform == "" {
+		xcodeProj, scheme, configuration, err := OpenArchivableProject(opts.ProjectPath, opts.Scheme, opts.Configuration)
+		if err != nil {
+			return out, fmt.Errorf("failed to open project: %s: %s", opts.ProjectPath, err)
+		}
+		platform, err = BuildableTargetPlatform(xcodeProj, scheme, configuration, opts.AdditionalOptions, XcodeBuild{XcodebuildPath: opts.XcodebuildPath, CmdFactory: s.cmdFactory, Logger: s.logger}, s.logger)
+		if err != nil {
+			return out, fmt.Errorf("failed to read project platform: %s: %s", opts.ProjectPath, err)
+		}
+	}
+	sim, err := platformSimulator(platform)
+	if err != nil {
+		return out, err
+	}
+	s.logger.Printf("Simulator SDK: %s", sim.sdk)
+
+	actions := []string{"build"}
+	if opts.PerformCleanAction {
+		actions = []string{"clean", "build"}
+	}
+
+	buildCmd := xcodebuild.NewCommandBuilder(opts.ProjectPath, actions...)
+	buildCmd.SetScheme(opts.Scheme)
+	buildCmd.SetConfiguration(opts.Configuration)
+
+	if opts.XcconfigContent != "" {
+		xcconfigWriter := xcconfig.NewWriter(s.pathProvider, s.fileManager, s.pathChecker, s.pathModifier)
+		xcconfigPath, err := xcconfigWriter.Write(opts.XcconfigContent)
+		if err != nil {
+			return out, fmt.Errorf("failed to write xcconfig file contents: %w", err)
+		}
+		buildCmd.SetXCConfigPath(xcconfigPath)
+	}
+
+	customOptions := []string{"-sdk", sim.sdk}
+	customOptions = append(customOptions, generateAdditionalOptions(sim.destinationPlatform, opts.AdditionalOptions)...)
+	customOptions = append(customOptions, buildParallelismArgs(opts.BuildParallelism)...)
+	customOptions = append(customOptions, xcodebuildVerbosityArgs(opts.XcodebuildVerbosity, opts.LogFormatter)...)
+	customOptions = append(customOptions, packageValidationArgs(opts.SkipPackagePluginValidation, opts.SkipMacroValidation, opts.XcodeMajorVersion, s.logger)...)
+	customOptions = append(customOptions, clonedSourcePackagesDirArgs(opts.ClonedSourcePackagesDirPath)...)
+	customOptions = append(customOptions, "CODE_SIGNING_ALLOWED=NO")
+	buildCmd.SetCustomOptions(customOptions)
+	buildCmdModel := newXcodebuildCommand(buildCmd, opts.XcodebuildPath, nil, opts.Envs)
+
+	logBuildParallelism(opts.BuildParallelism, s.logger)
+	s.logger.Infof("Starting the Simulator build ...")
+
+	xcodebuildLog, err := runArchiveCommand(buildCmdModel, opts.LogFormatter == "xcpretty", archiveMonitorOpts{
+		HeartbeatInterval:  opts.HeartbeatInterval,
+		HangThreshold:      opts.HangThreshold,
+		HangDiagnosticsDir: opts.HangDiagnosticsDir,
+		CommandFactory:     s.cmdFactory,
+		OverwriteOutputs:   opts.OverwriteOutputs,
+	}, s.logger)
+	out.XcodebuildBuildLog = xcodebuildLog
+	if err != nil || opts.LogFormatter == "xcodebuild" {
+		const lastLinesMsg = "\nLast lines of the Xcode's build log:"
+		if err != nil {
+			s.logger.Infof(colorstring.Red(lastLinesMsg))
+		} else {
+			s.logger.Infof(lastLinesMsg)
+		}
+		s.logger.Printf(stringutil.LastNLines(xcodebuildLog, 20))
+
+		s.logger.Warnf(fmt.Sprintf(`You can find the last couple of lines of Xcode's build log above, but the full log will be also available in the %s
+The log file will be stored in $BITRISE_DEPLOY_DIR, and its full path will be available in the $%s environment variable.`, xcodebuildArchiveLogFilename, xcodebuildArchiveLogPathEnvKey))
+	}
+	if err != nil {
+		return out, fmt.Errorf("failed to build the project for the Simulator: %w", err)
+	}
+
+	derivedDataDir, err := findProjectDerivedDataDir(defaultDerivedDataDir(), opts.ProjectPath)
+	if err != nil {
+		return out, fmt.Errorf("failed to find the project's DerivedData: %w", err)
+	}
+	if derivedDataDir == "" {
+		return out, fmt.Errorf("no DerivedData found for the project: %s", opts.ProjectPath)
+	}
+
+	appPath, err := findLatestSimulatorApp(filepath.Join(derivedDataDir, "Build", "Products"), sim.sdk)
+	if err != nil {
+		return out, err
+	}
+	s.logger.Printf("Simulator app: %s", appPath)
+	out.AppPath = appPath
+
+	return out, nil
+}
+
+// findLatestSimulatorApp returns the most recently built app in the Simulator SDK's products dirs (eg. Build/Products/Debug-iphonesimulator).
+func findLatestSimulatorApp(productsDir, sdk string) (string, error) {
+	appPaths, err := filepath.Glob(filepath.Join(productsDir, "*-"+sdk, "*.app"))
+	if err != nil {
+		return "", fmt.Errorf("failed to search for the Simulator app: %w", err)
+	}
+	if len(appPaths) == 0 {
+		return "", fmt.Errorf("no Simulator app found in: %s", productsDir)
+	}
+
+	modTimes := map[string]int64{}
+	for _, appPath := range appPaths {
+		info, err := os.Stat(appPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to check the Simulator app (%s): %w", appPath, err)
+		}
+		modTimes[appPath] = info.ModTime().UnixNano()
+	}
+
+	sort.SliceStable(appPaths, func(i, j int) bool {
+		return modTimes[appPaths[i]] > modTimes[appPaths[j]]
+	})
+	return appPaths[0], nil
+}
+
+// exportSimulatorApp exports the Simulator app directory and its zip into the OutputDir.
+func (s XcodebuildArchiver) exportSimulatorApp(simulatorAppPath, outputDir, artifactName string, outputPaths outputPathResolver) error {
+	appPath, err := outputPaths.resolve(filepath.Join(outputDir, artifactName+".app"))
+	if err != nil {
+		return err
+	}
+	if err := ExportOutputDir(s.cmdFactory, simulatorAppPath, appPath, bitriseAppDirPthEnvKey, s.logger); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", bitriseAppDirPthEnvKey, err)
+	}
+	s.logger.Donef("The Simulator app directory is now available in the Environment Variable: %s (value: %s)", bitriseAppDirPthEnvKey, appPath)
+
+	appZipPath, err := outputPaths.resolve(filepath.Join(outputDir, artifactName+".app.zip"))
+	if err != nil {
+		return err
+	}
+	if err := ExportOutputDirAsZip(s.cmdFactory, simulatorAppPath, appZipPath, bitriseSimulatorAppZipPthEnvKey, s.logger); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", bitriseSimulatorAppZipPthEnvKey, err)
+	}
+	s.logger.Donef("The Simulator app zip path is now available in the Environment Variable: %s (value: %s)", bitriseSimulatorAppZipPthEnvKey, appZipPath)
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findLatestSimulatorApp(t *testing.T) {
+	productsDir := t.TempDir()
+
+	_, err := findLatestSimulatorApp(productsDir, "iphonesimulator")
+	require.Error(t, err)
+
+	debugApp := filepath.Join(productsDir, "Debug-iphonesimulator", "Sample.app")
+	releaseApp := filepath.Join(productsDir, "Release-iphonesimulator", "Sample.app")
+	deviceApp := filepath.Join(productsDir, "Release-iphoneos", "Sample.app")
+	for _, pth := range []string{debugApp, releaseApp, deviceApp} {
+		require.NoError(t, os.MkdirAll(pth, 0755))
+	}
+
+	now := time.Now()
+	require.NoError(t, os.Chtimes(debugApp, now.Add(-time.Hour), now.Add(-time.Hour)))
+	require.NoError(t, os.Chtimes(releaseApp, now, now))
+	require.NoError(t, os.Chtimes(deviceApp, now.Add(time.Hour), now.Add(time.Hour)))
+
+	appPath, err := findLatestSimulatorApp(productsDir, "iphonesimulator")
+	require.NoError(t, err)
+	require.Equal(t, releaseApp, appPath)
+}
+
+func Test_platformSimulator(t *testing.T) {
+	tests := []struct {
+		platform Platform
+		want     simulator
+		wantErr  bool
+	}{
+		{platform: iOS, want: simulator{sdk: "iphonesimulator", destinationPlatform: "iOS Simulator"}},
+		{platform: tvOS, want: simulator{sdk: "appletvsimulator", destinationPlatform: "tvOS Simulator"}},
+		{platform: watchOS, want: simulator{sdk: "watchsimulator", destinationPlatform: "watchOS Simulator"}},
+		{platform: visionOS, want: simulator{sdk: "xrsimulator", destinationPlatform: "visionOS Simulator"}},
+		{platform: osX, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.platform), func(t *testing.T) {
+			got, err := platformSimulator(tt.platform)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_simulatorDestinationPlatform(t *testing.T) {
+	platform, ok := simulatorDestinationPlatform("generic/platform=tvOS Simulator")
+	require.True(t, ok)
+	require.Equal(t, tvOS, platform)
+
+	platform, ok = simulatorDestinationPlatform("generic/platform=visionOS Simulator")
+	require.True(t, ok)
+	require.Equal(t, visionOS, platform)
+
+	_, ok = simulatorDestinationPlatform("generic/platform=tvOS")
+	require.False(t, ok)
+}
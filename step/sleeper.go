@@ -0,0 +1,28 @@
+package step
+
+import "time"
+
+// Sleeper ...
+type Sleeper interface {
+	// Sleep pauses the current goroutine for at least the given duration.
+	Sleep(d time.Duration)
+}
+
+type timeSleeper struct {
+}
+
+// NewTimeSleeper ...
+func NewTimeSleeper() Sleeper {
+	return timeSleeper{}
+}
+
+// Sleep ...
+func (timeSleeper) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// WaitBeforeRetry waits the given delay before retrying a failed attempt.
+func (s XcodebuildArchiver) WaitBeforeRetry(delay time.Duration) {
+	s.logger.Printf("Waiting %s before retrying", delay)
+	s.sleeper.Sleep(delay)
+}
@@ -0,0 +1,64 @@
+package step
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePackageDependencies_waitsBetweenAttempts(t *testing.T) {
+	tests := []struct {
+		name       string
+		failPrefix string
+		wantErr    bool
+		wantWaits  []time.Duration
+	}{
+		{name: "succeeds at the first attempt", wantWaits: nil},
+		{
+			name:       "fails every attempt",
+			failPrefix: "xcodebuild",
+			wantErr:    true,
+			wantWaits:  []time.Duration{resolvePackagesRetryDelay, resolvePackagesRetryDelay},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := &recordingCommandFactory{failPrefix: tt.failPrefix}
+			sleeper := &recordingSleeper{}
+			archiver := XcodebuildArchiver{cmdFactory: factory, logger: log.NewLogger(), sleeper: sleeper}
+
+			err := archiver.ResolvePackageDependencies(ResolvePackageDependenciesOpts{
+				ProjectPath: "Sample.xcodeproj",
+				Scheme:      "Sample",
+				MaxAttempts: 3,
+				OutputDir:   t.TempDir(),
+			})
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantWaits, sleeper.waits)
+		})
+	}
+}
+
+func TestWaitBeforeRetry(t *testing.T) {
+	sleeper := &recordingSleeper{}
+	archiver := XcodebuildArchiver{logger: log.NewLogger(), sleeper: sleeper}
+
+	archiver.WaitBeforeRetry(30 * time.Second)
+
+	require.Equal(t, []time.Duration{30 * time.Second}, sleeper.waits)
+}
+
+// recordingSleeper records the waits instead of sleeping.
+type recordingSleeper struct {
+	waits []time.Duration
+}
+
+func (s *recordingSleeper) Sleep(d time.Duration) {
+	s.waits = append(s.waits, d)
+}
@@ -2,8 +2,10 @@ package step
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -45,9 +47,11 @@ const (
 	minSupportedXcodeMajorVersion = 9
 
 	// Deployed Outputs (moved to the OutputDir)
-	bitriseXCArchiveZipPthEnvKey = "BITRISE_XCARCHIVE_ZIP_PATH"
-	bitriseDSYMPthEnvKey         = "BITRISE_DSYM_PATH"
-	bitriseIPAPthEnvKey          = "BITRISE_IPA_PATH"
+	bitriseXCArchiveZipPthEnvKey  = "BITRISE_XCARCHIVE_ZIP_PATH"
+	bitriseDSYMPthEnvKey          = "BITRISE_DSYM_PATH"
+	bitriseIPAPthEnvKey           = "BITRISE_IPA_PATH"
+	bitriseExportOptionsPthEnvKey = "BITRISE_EXPORT_OPTIONS_PLIST_PATH"
+	bitriseBCSymbolMapsPthEnvKey  = "BITRISE_BCSYMBOLMAPS_PATH"
 
 	// Deployed logs
 	xcodebuildArchiveLogPathEnvKey       = "BITRISE_XCODEBUILD_ARCHIVE_LOG_PATH"
@@ -69,28 +73,71 @@ const (
 
 // Inputs ...
 type Inputs struct {
-	ExportMethod               string `env:"distribution_method,opt[app-store,ad-hoc,enterprise,development]"`
-	UploadBitcode              bool   `env:"upload_bitcode,opt[yes,no]"`
-	CompileBitcode             bool   `env:"compile_bitcode,opt[yes,no]"`
-	ICloudContainerEnvironment string `env:"icloud_container_environment"`
-	ExportDevelopmentTeam      string `env:"export_development_team"`
+	ExportMethod                string `env:"distribution_method,opt[app-store,ad-hoc,enterprise,development]"`
+	AdditionalExportMethods     string `env:"additional_distribution_methods"`
+	FailOnAdditionalExportError bool   `env:"fail_on_additional_export_error,opt[yes,no]"`
+	ConfigurationPerMethod      string `env:"configuration_per_method"`
+	UploadBitcode               bool   `env:"upload_bitcode,opt[yes,no]"`
+	CompileBitcode              bool   `env:"compile_bitcode,opt[yes,no]"`
+	ICloudContainerEnvironment  string `env:"icloud_container_environment"`
+	ExportDevelopmentTeam       string `env:"export_development_team"`
+	Thinning                    string `env:"thinning"`
+	ManifestAppURL              string `env:"manifest_app_url"`
+	ManifestDisplayImageURL     string `env:"manifest_display_image_url"`
+	ManifestFullSizeImageURL    string `env:"manifest_full_size_image_url"`
 
 	ExportOptionsPlistContent string `env:"export_options_plist_content"`
+	FailOnIgnoredExportInputs bool   `env:"fail_on_ignored_export_inputs,opt[yes,no]"`
 
-	LogFormatter       string `env:"log_formatter,opt[xcpretty,xcodebuild]"`
-	ProjectPath        string `env:"project_path,file"`
-	Scheme             string `env:"scheme,required"`
+	VerifyExportedIPA bool   `env:"verify_exported_ipa,opt[yes,no]"`
+	BundleIdentifier  string `env:"bundle_identifier"`
+
+	LogFormatter            string `env:"log_formatter,opt[xcpretty,xcodebuild]"`
+	FailOnLogFormatterError bool   `env:"fail_on_log_formatter_error,opt[yes,no]"`
+	XcodebuildVerbosity     string `env:"xcodebuild_verbosity,opt[default,quiet,verbose]"`
+
+	ProjectPath        string `env:"project_path,required"`
+	Scheme             string `env:"scheme"`
 	Configuration      string `env:"configuration"`
 	OutputDir          string `env:"output_dir,required"`
 	PerformCleanAction bool   `env:"perform_clean_action,opt[yes,no]"`
 	XcodebuildOptions  string `env:"xcodebuild_options"`
-	XcconfigContent    string `env:"xcconfig_content"`
+	XcodebuildPath     string `env:"xcodebuild_path,required"`
+	BuildParallelism   int    `env:"build_parallelism"`
+
+	SkipPackagePluginValidation bool   `env:"skip_package_plugin_validation,opt[yes,no]"`
+	SkipMacroValidation         bool   `env:"skip_macro_validation,opt[yes,no]"`
+	PreArchiveScript            string `env:"pre_archive_script"`
+	Destination                 string `env:"destination"`
+	PlatformInput               string `env:"platform,opt[auto,ios,tvos,watchos,macos,visionos]"`
+	XcconfigContent             string `env:"xcconfig_content"`
+
+	AllowProvisioningUpdatesInput string `env:"allow_provisioning_updates,opt[auto,yes,no]"`
+
+	SkipCodesigning   bool `env:"skip_codesigning,opt[yes,no]"`
+	BuildForSimulator bool `env:"build_for_simulator,opt[yes,no]"`
 
-	ExportAllDsyms bool   `env:"export_all_dsyms,opt[yes,no]"`
-	ArtifactName   string `env:"artifact_name"`
-	VerboseLog     bool   `env:"verbose_log,opt[yes,no]"`
+	AdditionalEnvVars       string          `env:"additional_env_vars"`
+	AdditionalSecretEnvVars stepconf.Secret `env:"additional_secret_env_vars"`
 
-	CacheLevel string `env:"cache_level,opt[none,swift_packages]"`
+	ExportAllDsyms    bool   `env:"export_all_dsyms,opt[yes,no]"`
+	ExportNestedApps  bool   `env:"export_nested_apps,opt[yes,no]"`
+	ArtifactName      string `env:"artifact_name"`
+	OutputDirStrategy string `env:"output_dir_strategy,opt[flat,per-run]"`
+	OverwriteOutputs  bool   `env:"overwrite_outputs,opt[yes,no]"`
+	KeepFailedArchive bool   `env:"keep_failed_archive,opt[yes,no]"`
+	CleanupTempDir    bool   `env:"cleanup_temp_dir,opt[yes,no]"`
+	VerboseLog        bool   `env:"verbose_log,opt[yes,no]"`
+
+	FirebaseAppID             string `env:"firebase_app_id"`
+	FirebaseUploadSymbolsPath string `env:"firebase_upload_symbols_path"`
+	FailOnSymbolUploadError   bool   `env:"fail_on_symbol_upload_error,opt[yes,no]"`
+
+	CacheLevelInput string `env:"cache_level,opt[none,swift_packages,all]"`
+
+	ResolvePackageDependencies           bool   `env:"resolve_package_dependencies,opt[yes,no]"`
+	ResolvePackageDependenciesRetryCount int    `env:"resolve_package_dependencies_retry_count"`
+	ClonedSourcePackagesDirPath          string `env:"cloned_source_packages_path"`
 
 	CodeSigningAuthSource           string          `env:"automatic_code_signing,opt[off,api-key,apple-id]"`
 	CertificateURLList              string          `env:"certificate_url_list"`
@@ -101,12 +148,31 @@ type Inputs struct {
 	TestDeviceListPath              string          `env:"test_device_list_path"`
 	MinDaysProfileValid             int             `env:"min_profile_validity,required"`
 	FallbackProvisioningProfileURLs string          `env:"fallback_provisioning_profile_url_list"`
+	CertificatePath                 string          `env:"certificate_path"`
+	CertificatePassphrase           stepconf.Secret `env:"certificate_passphrase"`
+	ProvisioningProfilePaths        string          `env:"provisioning_profile_paths"`
 	APIKeyPath                      stepconf.Secret `env:"api_key_path"`
 	APIKeyID                        string          `env:"api_key_id"`
 	APIKeyIssuerID                  string          `env:"api_key_issuer_id"`
 	BuildURL                        string          `env:"BITRISE_BUILD_URL"`
+	GitCommit                       string          `env:"BITRISE_GIT_COMMIT"`
+	GitBranch                       string          `env:"BITRISE_GIT_BRANCH"`
 	BuildAPIToken                   stepconf.Secret `env:"BITRISE_BUILD_API_TOKEN"`
-	MaxRetryCount             	    int             `env:"max_retry_count"`
+	MaxRetryCount                   int             `env:"max_retry_count"`
+	MaxRetryCountLimit              int             `env:"max_retry_count_limit"`
+	RetryCleanMode                  string          `env:"retry_clean_mode,opt[none,clean,deriveddata,full]"`
+	RetryCleanupTiers               string          `env:"retry_cleanup_tiers"`
+	RetryPolicies                   string          `env:"retry_policies"`
+	RetryPreservesDerivedData       bool            `env:"retry_preserves_derived_data,opt[yes,no]"`
+	MinFreeDiskMB                   int             `env:"min_free_disk_mb"`
+	MaxAllowedWarnings              int             `env:"max_allowed_warnings"`
+	HeartbeatSeconds                int             `env:"heartbeat_seconds"`
+	CaptureHangDiagnostics          bool            `env:"capture_hang_diagnostics,opt[yes,no]"`
+	HangThresholdSeconds            int             `env:"hang_threshold_seconds"`
+	PrintProjectInfo                bool            `env:"print_project_info,opt[yes,no]"`
+
+	NotifyWebhookURL stepconf.Secret `env:"notify_webhook_url"`
+	NotifyFormat     string          `env:"notify_format,opt[generic,slack]"`
 }
 
 // Config ...
@@ -114,7 +180,24 @@ type Config struct {
 	Inputs
 	XcodeMajorVersion           int
 	XcodebuildAdditionalOptions []string
+	AllowProvisioningUpdates    bool
+	RetryCleanupPlan            RetryCleanupPlan
+	AdditionalExportMethodList  []string
+	ConfigurationArchives       []ConfigurationArchive
+	OTAManifest                 exportoptions.Manifest
+	TempWorkDir                 string
+	GitInfo                     GitInfo
+	ArchiveRetryPolicies        RetryPolicies
+	CacheLevel                  CacheLevel
+	XcodebuildEnvVars           []EnvVar
+	Platform                    Platform // empty if detected from the project
+	SDK                         string
 	CodesignManager             *codesign.Manager // nil if automatic code signing is "off"
+	// AdditionalExportCodesignManagers are the code signing managers of the AdditionalExportMethodList, nil if automatic code signing is "off"
+	AdditionalExportCodesignManagers map[string]*codesign.Manager
+	CodesignFiles                    CodesignFiles
+	// ProjectGenerated is true if the project does not exist yet, and is expected to be generated by the PreArchiveScript
+	ProjectGenerated bool
 }
 
 // XcodebuildArchiver ...
@@ -127,10 +210,12 @@ type XcodebuildArchiver struct {
 	fileManager          fileutil.FileManager
 	logger               log.Logger
 	cmdFactory           command.Factory
+	diskSpaceChecker     DiskSpaceChecker
+	sleeper              Sleeper
 }
 
 // NewXcodebuildArchiver ...
-func NewXcodebuildArchiver(xcodeVersionProvider XcodeVersionProvider, stepInputParser stepconf.InputParser, pathProvider pathutil.PathProvider, pathChecker pathutil.PathChecker, pathModifier pathutil.PathModifier, fileManager fileutil.FileManager, logger log.Logger, cmdFactory command.Factory) XcodebuildArchiver {
+func NewXcodebuildArchiver(xcodeVersionProvider XcodeVersionProvider, stepInputParser stepconf.InputParser, pathProvider pathutil.PathProvider, pathChecker pathutil.PathChecker, pathModifier pathutil.PathModifier, fileManager fileutil.FileManager, logger log.Logger, cmdFactory command.Factory, diskSpaceChecker DiskSpaceChecker, sleeper Sleeper) XcodebuildArchiver {
 	return XcodebuildArchiver{
 		xcodeVersionProvider: xcodeVersionProvider,
 		stepInputParser:      stepInputParser,
@@ -140,6 +225,8 @@ func NewXcodebuildArchiver(xcodeVersionProvider XcodeVersionProvider, stepInputP
 		fileManager:          fileManager,
 		logger:               logger,
 		cmdFactory:           cmdFactory,
+		diskSpaceChecker:     diskSpaceChecker,
+		sleeper:              sleeper,
 	}
 }
 
@@ -173,6 +260,80 @@ func (s XcodebuildArchiver) ProcessInputs() (Config, error) {
 		return Config{}, fmt.Errorf("`-xcconfig` option found in XcodebuildOptions (`xcodebuild_options`), please clear Build settings (xcconfig) (`xcconfig_content`) input as only one can be set")
 	}
 
+	config.Destination = strings.TrimSpace(config.Destination)
+	if config.Destination != "" {
+		if sliceutil.IsStringInSlice("-destination", config.XcodebuildAdditionalOptions) {
+			return Config{}, fmt.Errorf("`-destination` option found in XcodebuildOptions (`xcodebuild_options`), please clear Destination (`destination`) input as only one can be set")
+		}
+		if err := validateDestination(config.Destination); err != nil {
+			return Config{}, fmt.Errorf("issue with input Destination: %w", err)
+		}
+	}
+
+	if config.BuildParallelism < 0 {
+		return Config{}, fmt.Errorf("issue with input BuildParallelism: should be a positive number, or 0 to keep xcodebuild's automatic parallelism")
+	}
+	if config.BuildParallelism > 0 && sliceutil.IsStringInSlice("-jobs", config.XcodebuildAdditionalOptions) {
+		return Config{}, fmt.Errorf("`-jobs` option found in XcodebuildOptions (`xcodebuild_options`), please clear Build parallelism (`build_parallelism`) input as only one can be set")
+	}
+
+	if config.MaxRetryCountLimit < 1 {
+		return Config{}, fmt.Errorf("issue with input MaxRetryCountLimit: should be a positive number")
+	}
+	config.MaxRetryCount, err = capMaxRetryCount(config.MaxRetryCount, config.MaxRetryCountLimit, s.logger)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input MaxRetryCount: %w", err)
+	}
+	s.logger.Printf("Maximum archive attempts: %d", config.MaxRetryCount)
+
+	if config.PreArchiveScript != "" && !config.PrintProjectInfo {
+		// the script runs at the start of each attempt and might generate the project,
+		// in this case the project and the scheme are validated by the attempts
+		config.ProjectGenerated, err = s.isProjectGenerated(config.ProjectPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("issue with input ProjectPath: %w", err)
+		}
+	}
+	if config.ProjectGenerated {
+		config.ProjectPath, err = s.pathModifier.AbsPath(config.ProjectPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("issue with input ProjectPath: failed to get absolute project path: %w", err)
+		}
+		if config.Scheme == "" {
+			return Config{}, fmt.Errorf("issue with input Scheme: required if the project is generated by the pre-archive script")
+		}
+		s.logger.Printf("The project (%s) does not exist yet, it is validated after the pre-archive script generated it", config.ProjectPath)
+		if config.ResolvePackageDependencies {
+			s.logger.Warnf("ResolvePackageDependencies (resolve_package_dependencies) is ignored, the package dependencies are resolved by the archive attempts after the pre-archive script")
+			config.ResolvePackageDependencies = false
+		}
+	} else {
+		config.ProjectPath, err = s.resolveProjectPath(config.ProjectPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("issue with input ProjectPath: %w", err)
+		}
+	}
+
+	if config.XcodebuildPath != defaultXcodebuildPath {
+		config.XcodebuildPath, err = resolveXcodebuildPath(config.XcodebuildPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("issue with input XcodebuildPath: %w", err)
+		}
+	}
+
+	if config.PrintProjectInfo {
+		// the project info helps to fix the scheme, signing and export inputs, so they are not validated
+		s.logger.Println()
+		s.logger.Infof("Resolving scheme:")
+		scheme, err := s.resolveScheme(config.XcodebuildPath, config.ProjectPath, config.Scheme)
+		if err != nil {
+			s.logger.Warnf("Failed to resolve the scheme, its build settings will not be printed: %s", err)
+			scheme = ""
+		}
+		config.Scheme = scheme
+		return config, nil
+	}
+
 	if config.ExportOptionsPlistContent != "" {
 		var options map[string]interface{}
 		if _, err := plist.Unmarshal([]byte(config.ExportOptionsPlistContent), &options); err != nil {
@@ -180,10 +341,6 @@ func (s XcodebuildArchiver) ProcessInputs() (Config, error) {
 		}
 	}
 
-	if filepath.Ext(config.ProjectPath) != ".xcodeproj" && filepath.Ext(config.ProjectPath) != ".xcworkspace" {
-		return Config{}, fmt.Errorf("issue with input ProjectPath: should be and .xcodeproj or .xcworkspace path")
-	}
-
 	s.logger.Infof("Xcode version:")
 
 	// Detect Xcode major version
@@ -199,6 +356,48 @@ func (s XcodebuildArchiver) ProcessInputs() (Config, error) {
 	}
 	config.XcodeMajorVersion = int(xcodeMajorVersion)
 
+	config.Platform, config.SDK, err = parsePlatformInput(config.PlatformInput)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input Platform: %w", err)
+	}
+
+	// a Simulator build exports nothing, so the export related inputs are validated only if it is not a Simulator build
+	if platform, ok := simulatorDestinationPlatform(config.Destination); ok {
+		config.BuildForSimulator = true
+		if config.Platform == "" {
+			config.Platform = platform
+		}
+	}
+	if config.BuildForSimulator {
+		if config.Platform != "" {
+			if _, err := platformSimulator(config.Platform); err != nil {
+				return Config{}, fmt.Errorf("issue with input BuildForSimulator: %w", err)
+			}
+		}
+		s.logger.Println()
+		s.logger.Warnf("Building for the Simulator, no archive, IPA and dSYMs are exported")
+		config.SkipCodesigning = true
+	}
+
+	if config.Platform != "" {
+		if config.SDK != "" && sliceutil.IsStringInSlice("-sdk", config.XcodebuildAdditionalOptions) {
+			return Config{}, fmt.Errorf("`-sdk` option found in XcodebuildOptions (`xcodebuild_options`), please set Platform (`platform`) input to `auto` as only one can be set")
+		}
+		exportMethod := config.ExportMethod
+		if config.SkipCodesigning || config.ExportOptionsPlistContent != "" {
+			exportMethod = ""
+		}
+		if err := validatePlatform(config.Platform, exportMethod, config.XcodeMajorVersion); err != nil {
+			return Config{}, fmt.Errorf("issue with input Platform: %w", err)
+		}
+	}
+
+	config.Thinning, err = parseThinning(config.Thinning)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input Thinning: %w", err)
+	}
+	config.OTAManifest = otaManifest(config.Inputs)
+
 	// Validation ExportOptionsPlistContent
 	exportOptionsPlistContent := strings.TrimSpace(config.ExportOptionsPlistContent)
 	if exportOptionsPlistContent != config.ExportOptionsPlistContent {
@@ -208,44 +407,263 @@ func (s XcodebuildArchiver) ProcessInputs() (Config, error) {
 	}
 
 	if exportOptionsPlistContent != "" {
-		s.logger.Println()
-		s.logger.Warnf("Ignoring the following options because ExportOptionsPlistContent provided:")
-		s.logger.Printf("- DistributionMethod: %s", config.ExportMethod)
-		s.logger.Printf("- UploadBitcode: %s", config.UploadBitcode)
-		s.logger.Printf("- CompileBitcode: %s", config.CompileBitcode)
-		s.logger.Printf("- ExportDevelopmentTeam: %s", config.ExportDevelopmentTeam)
-		s.logger.Printf("- ICloudContainerEnvironment: %s", config.ICloudContainerEnvironment)
-		s.logger.Println()
+		if ignoredInputs := ignoredExportInputs(config.Inputs); len(ignoredInputs) > 0 {
+			if config.FailOnIgnoredExportInputs {
+				return Config{}, fmt.Errorf("ExportOptionsPlistContent (`export_options_plist_content`) is provided, please clear the following inputs as they would be ignored: %s", strings.Join(ignoredInputs, ", "))
+			}
+
+			s.logger.Println()
+			s.logger.Warnf("Ignoring the following options because ExportOptionsPlistContent provided:")
+			for _, input := range ignoredInputs {
+				s.logger.Warnf("- %s", input)
+			}
+			s.logger.Println()
+		}
 	}
 	config.ExportOptionsPlistContent = exportOptionsPlistContent
 
-	absProjectPath, err := filepath.Abs(config.ProjectPath)
+	// SkipCodesigning is already set for the Simulator builds, their ignored export inputs are only warned about
+	if config.ExportOptionsPlistContent == "" && !config.SkipCodesigning {
+		if err := validateThinning(config.Thinning, config.ExportMethod); err != nil {
+			return Config{}, fmt.Errorf("issue with input Thinning: %w", err)
+		}
+		if err := validateOTAManifest(config.OTAManifest, config.ExportMethod); err != nil {
+			return Config{}, fmt.Errorf("issue with input Manifest: %w", err)
+		}
+	}
+
+	if config.BundleIdentifier != "" && !config.VerifyExportedIPA {
+		s.logger.Warnf("Expected bundle identifier (bundle_identifier) is only checked if Verify exported IPA (verify_exported_ipa) is set, ignoring it")
+	}
+
+	config.AdditionalExportMethodList, err = parseAdditionalExportMethods(config.AdditionalExportMethods, config.ExportMethod)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input AdditionalExportMethods: %w", err)
+	}
+	if len(config.AdditionalExportMethodList) > 0 && config.ExportOptionsPlistContent != "" {
+		return Config{}, fmt.Errorf("ExportOptionsPlistContent (`export_options_plist_content`) is provided, please clear Additional distribution methods (`additional_distribution_methods`) input as the export options can only be generated for the additional methods")
+	}
+	configurationPerMethod, err := parseConfigurationPerMethod(config.ConfigurationPerMethod, config.ExportMethod, config.AdditionalExportMethodList)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input ConfigurationPerMethod: %w", err)
+	}
+
+	if config.CertificatePath != "" {
+		if config.CertificatePath, err = s.pathModifier.AbsPath(config.CertificatePath); err != nil {
+			return Config{}, fmt.Errorf("issue with input CertificatePath: %w", err)
+		}
+		if err := validateCodesignCertificate(config.CertificatePath, config.CertificatePassphrase); err != nil {
+			return Config{}, fmt.Errorf("issue with input CertificatePath: %w", err)
+		}
+		if config.CodeSigningAuthSource == codeSignSourceOff && (config.KeychainPath == "" || config.KeychainPassword == "") {
+			return Config{}, fmt.Errorf("issue with input CertificatePath: KeychainPath and KeychainPassword are required to import the certificate")
+		}
+	}
+	profiles, err := parseProvisioningProfileFiles(config.ProvisioningProfilePaths)
 	if err != nil {
-		return Config{}, fmt.Errorf("failed to get absolute project path, error: %s", err)
+		return Config{}, fmt.Errorf("issue with input ProvisioningProfilePaths: %w", err)
+	}
+	config.CodesignFiles = CodesignFiles{
+		CertificatePath:       config.CertificatePath,
+		CertificatePassphrase: config.CertificatePassphrase,
+		ProvisioningProfiles:  profiles,
+	}
+
+	if !config.ProjectGenerated {
+		s.logger.Println()
+		s.logger.Infof("Resolving scheme:")
+		scheme, err := s.resolveScheme(config.XcodebuildPath, config.ProjectPath, config.Scheme)
+		if err != nil {
+			return Config{}, fmt.Errorf("issue with input Scheme: %w", err)
+		}
+		if scheme != config.Scheme {
+			s.logger.Printf("No scheme provided, using the only shared scheme: %s", scheme)
+		}
+		config.Scheme = scheme
+	}
+
+	config.GitInfo = s.resolveGitInfo(config.GitCommit, config.GitBranch, config.ProjectPath)
+	if config.ArtifactName != "" {
+		artifactName, missing := resolveArtifactNamePlaceholders(config.ArtifactName, config.GitInfo)
+		if len(missing) > 0 {
+			s.logger.Warnf("No value found for the %s placeholders of ArtifactName, resolved them to empty", strings.Join(missing, ", "))
+		}
+		config.ArtifactName = artifactName
 	}
-	config.ProjectPath = absProjectPath
 
 	// abs out dir pth
-	absOutputDir, err := v1pathutil.AbsPath(config.OutputDir)
+	absOutputDir, err := s.pathModifier.AbsPath(config.OutputDir)
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to expand OutputDir (%s), error: %s", config.OutputDir, err)
 	}
 	config.OutputDir = absOutputDir
 
-	if exist, err := v1pathutil.IsPathExists(config.OutputDir); err != nil {
-		return Config{}, fmt.Errorf("failed to check if OutputDir exist, error: %s", err)
-	} else if !exist {
-		if err := os.MkdirAll(config.OutputDir, 0777); err != nil {
-			return Config{}, fmt.Errorf("failed to create OutputDir (%s), error: %s", config.OutputDir, err)
+	if config.OutputDirStrategy == outputDirStrategyPerRun {
+		config.OutputDir = perRunOutputDir(config.OutputDir, config.Scheme, time.Now())
+	}
+
+	if err := s.prepareOutputDir(config.OutputDir); err != nil {
+		return Config{}, fmt.Errorf("issue with input OutputDir: %w", err)
+	}
+
+	if config.ClonedSourcePackagesDirPath != "" {
+		if sliceutil.IsStringInSlice("-clonedSourcePackagesDirPath", config.XcodebuildAdditionalOptions) {
+			return Config{}, fmt.Errorf("`-clonedSourcePackagesDirPath` option found in XcodebuildOptions (`xcodebuild_options`), please clear Cloned source packages path (`cloned_source_packages_path`) input as only one can be set")
+		}
+
+		absClonedSourcePackagesDirPath, err := s.pathModifier.AbsPath(config.ClonedSourcePackagesDirPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to expand ClonedSourcePackagesDirPath (%s), error: %s", config.ClonedSourcePackagesDirPath, err)
+		}
+		config.ClonedSourcePackagesDirPath = absClonedSourcePackagesDirPath
+	}
+
+	envVars, err := parseEnvVars(config.AdditionalEnvVars, false)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input AdditionalEnvVars: %w", err)
+	}
+	secretEnvVars, err := parseEnvVars(string(config.AdditionalSecretEnvVars), true)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input AdditionalSecretEnvVars: %w", err)
+	}
+	config.XcodebuildEnvVars = append(envVars, secretEnvVars...)
+
+	retryCleanupPlan, err := ParseRetryCleanupPlan(config.RetryCleanupTiers)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input RetryCleanupTiers: %w", err)
+	}
+	config.RetryCleanupPlan, err = RetryCleanupPlanForMode(RetryCleanMode(config.RetryCleanMode), retryCleanupPlan)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input RetryCleanMode: %w", err)
+	}
+
+	retryPolicies, err := ParseRetryPolicies(config.RetryPolicies, config.MaxRetryCountLimit, s.logger)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input RetryPolicies: %w", err)
+	}
+	config.ArchiveRetryPolicies = retryPolicies
+
+	cacheLevel, err := ParseCacheLevel(config.CacheLevelInput)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input CacheLevel: %w", err)
+	}
+	config.CacheLevel = cacheLevel
+
+	switch config.AllowProvisioningUpdatesInput {
+	case "yes":
+		config.AllowProvisioningUpdates = true
+	case "no":
+		config.AllowProvisioningUpdates = false
+		if config.CodeSigningAuthSource == codeSignSourceAPIKey {
+			s.logger.Warnf("AllowProvisioningUpdates (allow_provisioning_updates) is no: the App Store Connect API key of Automatic code signing is not passed to xcodebuild, Xcode can not update the provisioning profiles")
+		}
+	default:
+		config.AllowProvisioningUpdates = config.CodeSigningAuthSource == codeSignSourceAPIKey
+	}
+
+	if config.CaptureHangDiagnostics && config.HangThresholdSeconds <= 0 {
+		return Config{}, fmt.Errorf("issue with input HangThresholdSeconds: should be greater than 0 if CaptureHangDiagnostics is enabled")
+	}
+
+	if config.XcodebuildVerbosity == xcodebuildVerbosityQuiet {
+		if config.LogFormatter == "xcpretty" {
+			s.logger.Warnf("XcodebuildVerbosity (xcodebuild_verbosity) quiet is not passed to xcodebuild with the xcpretty log formatter, as xcpretty already condenses the output")
+		} else if config.CaptureHangDiagnostics {
+			s.logger.Warnf("XcodebuildVerbosity (xcodebuild_verbosity) is quiet: xcodebuild prints only warnings and errors, long silent periods might be captured as hangs")
+		}
+	}
+
+	if !config.SkipCodesigning && len(configurationPerMethod) > 0 {
+		if config.ProjectGenerated {
+			return Config{}, fmt.Errorf("issue with input ConfigurationPerMethod: the project's configurations can not be read before the pre-archive script generated the project")
+		}
+		defaultConfiguration, configurations, err := projectConfigurations(config.ProjectPath, config.Scheme, config.Configuration)
+		if err != nil {
+			return Config{}, fmt.Errorf("issue with input ConfigurationPerMethod: failed to read the project's configurations: %w", err)
+		}
+		if err := validateConfigurations(configurationPerMethod, configurations); err != nil {
+			return Config{}, fmt.Errorf("issue with input ConfigurationPerMethod: %w", err)
+		}
+
+		primary, archives := groupExportsByConfiguration(defaultConfiguration, config.ExportMethod, config.AdditionalExportMethodList, configurationPerMethod)
+		config.Configuration = primary.Configuration
+		config.AdditionalExportMethodList = primary.AdditionalExportMethods
+		config.ConfigurationArchives = archives
+
+		s.logger.Println()
+		s.logger.Infof("Archives per configuration:")
+		for _, archive := range append([]ConfigurationArchive{primary}, archives...) {
+			s.logger.Printf("- %s: %s", archive.Configuration, strings.Join(append([]string{archive.ExportMethod}, archive.AdditionalExportMethods...), ", "))
 		}
 	}
 
-	if config.CodeSigningAuthSource != codeSignSourceOff {
+	if config.SkipCodesigning {
+		s.logger.Println()
+		s.logger.Warnf("Code signing is skipped, the archive is unsigned and no IPA is exported")
+		if config.CodeSigningAuthSource != codeSignSourceOff {
+			s.logger.Warnf("- Ignoring Automatic code signing method (automatic_code_signing): %s", config.CodeSigningAuthSource)
+		}
+		if config.ExportOptionsPlistContent != "" {
+			s.logger.Warnf("- Ignoring Custom export options plist content (export_options_plist_content)")
+		}
+		if config.ExportMethod != string(exportoptions.MethodDevelopment) {
+			s.logger.Warnf("- Ignoring Distribution method (distribution_method): %s", config.ExportMethod)
+		}
+		if config.AdditionalExportMethods != "" {
+			s.logger.Warnf("- Ignoring Additional distribution methods (additional_distribution_methods)")
+		}
+		if len(configurationPerMethod) > 0 {
+			s.logger.Warnf("- Ignoring Configuration per distribution method (configuration_per_method)")
+		}
+		if config.Thinning != exportoptions.ThinningNone {
+			s.logger.Warnf("- Ignoring Thinning (thinning): %s", config.Thinning)
+		}
+		if !config.OTAManifest.IsEmpty() {
+			s.logger.Warnf("- Ignoring the over-the-air installation manifest URLs (manifest_app_url, manifest_display_image_url, manifest_full_size_image_url)")
+		}
+		if config.VerifyExportedIPA {
+			s.logger.Warnf("- Ignoring Verify exported IPA (verify_exported_ipa)")
+			config.VerifyExportedIPA = false
+		}
+		if !config.CodesignFiles.IsEmpty() {
+			s.logger.Warnf("- Ignoring the code signing files (certificate_path, provisioning_profile_paths)")
+			config.CodesignFiles = CodesignFiles{}
+		}
+		config.AllowProvisioningUpdates = false
+	} else if config.CodeSigningAuthSource != codeSignSourceOff {
+		if config.ProjectGenerated {
+			return Config{}, fmt.Errorf("issue with input CodeSigningAuthSource: automatic code signing reads the project, which does not exist before the pre-archive script generated it")
+		}
 		codesignManager, err := s.createCodesignManager(config)
 		if err != nil {
 			return Config{}, fmt.Errorf("failed to prepare automatic code signing: %w", err)
 		}
 		config.CodesignManager = &codesignManager
+		config.AdditionalExportCodesignManagers, err = s.createAdditionalExportCodesignManagers(config, config.AdditionalExportMethodList)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to prepare automatic code signing: %w", err)
+		}
+
+		// the code signing assets depend on the archive's configuration and distribution method
+		for i, archive := range config.ConfigurationArchives {
+			archiveConfig := config
+			archiveConfig.Configuration = archive.Configuration
+			archiveConfig.ExportMethod = archive.ExportMethod
+			codesignManager, err := s.createCodesignManager(archiveConfig)
+			if err != nil {
+				return Config{}, fmt.Errorf("failed to prepare automatic code signing of the %s configuration: %w", archive.Configuration, err)
+			}
+			config.ConfigurationArchives[i].CodesignManager = &codesignManager
+			config.ConfigurationArchives[i].AdditionalExportCodesignManagers, err = s.createAdditionalExportCodesignManagers(archiveConfig, archive.AdditionalExportMethods)
+			if err != nil {
+				return Config{}, fmt.Errorf("failed to prepare automatic code signing of the %s configuration: %w", archive.Configuration, err)
+			}
+		}
+	}
+
+	config.TempWorkDir, err = s.createTempWorkDir()
+	if err != nil {
+		return Config{}, err
 	}
 
 	return config, nil
@@ -304,27 +722,65 @@ func (s XcodebuildArchiver) EnsureDependencies(opts EnsureDependenciesOpts) erro
 // RunOpts ...
 type RunOpts struct {
 	// Shared
-	ProjectPath       string
-	Scheme            string
-	Configuration     string
-	LogFormatter      string
-	XcodeMajorVersion int
-	ArtifactName      string
+	ProjectPath         string
+	Scheme              string
+	Configuration       string
+	LogFormatter        string
+	XcodebuildVerbosity string
+	XcodeMajorVersion   int
+	ArtifactName        string
+	OutputDir           string
+	OverwriteOutputs    bool
+	MinFreeDiskMB       int
+	HeartbeatInterval   time.Duration
+	HangThreshold       time.Duration // 0 if hang diagnostics are disabled
+	XcodebuildPath      string
+	Attempt             int // 1-based index of the archive attempt
+	BuildParallelism    int // 0 keeps xcodebuild's automatic parallelism
+	PreArchiveScript    string
+	ProjectGenerated    bool // true if the project and the scheme are validated after the PreArchiveScript
+	TempWorkDir         string
+
+	SkipPackagePluginValidation bool
+	SkipMacroValidation         bool
 
 	// Code signing, nil if automatic code signing is "off"
-	CodesignManager *codesign.Manager
+	CodesignManager                  *codesign.Manager
+	AdditionalExportCodesignManagers map[string]*codesign.Manager
+	AllowProvisioningUpdates         bool
+	SkipCodesigning                  bool
+	BuildForSimulator                bool
+
+	// Manual code signing, the keychain holding the installed certificates
+	KeychainPath     string
+	KeychainPassword stepconf.Secret
+	// CodesignFiles are installed before the archive, its certificate is imported into the keychain
+	// if automatic code signing is "off", otherwise it is passed to the CodesignManager
+	CodesignFiles CodesignFiles
 
 	// Archive
 	PerformCleanAction          bool
 	XcconfigContent             string
 	XcodebuildAdditionalOptions []string
-	CacheLevel                  string
+	Destination                 string
+	Platform                    Platform // empty if detected from the project
+	SDK                         string
+	CacheLevel                  CacheLevel
+	XcodebuildEnvVars           []EnvVar
+
+	// Swift packages
+	ClonedSourcePackagesDirPath string
+	PackageDependenciesResolved bool // true if the package dependencies were resolved in a separate phase
 
 	// IPA Export
 	CustomExportOptionsPlistContent string
 	ExportMethod                    string
+	AdditionalExportMethods         []string
+	FailOnAdditionalExportError     bool
 	ICloudContainerEnvironment      string
 	ExportDevelopmentTeam           string
+	Thinning                        string
+	OTAManifest                     exportoptions.Manifest
 	UploadBitcode                   bool
 	CompileBitcode                  bool
 }
@@ -332,14 +788,28 @@ type RunOpts struct {
 // RunResult ...
 type RunResult struct {
 	Archive      *xcarchive.IosArchive
+	ArchivePath  string
 	ArtifactName string
-
-	ExportOptionsPath string
-	IPAExportDir      string
+	Platform     Platform
+	// UnsignedArchivePath is the path of the successfully created archive, if code signing was skipped
+	UnsignedArchivePath string
+	// SimulatorAppPath is the path of the built app, if building for the Simulator
+	SimulatorAppPath string
+
+	ExportOptionsPath    string
+	IPAExportDir         string
+	AdditionalIPAExports []AdditionalIPAExport
+	// AdditionalExportErr is the failure of an additional distribution method's export, if FailOnAdditionalExportError is set.
+	// It is not returned as the error of the Run, as re-running the archive would not fix the export.
+	AdditionalExportErr error
 
 	XcodebuildArchiveLog       string
 	XcodebuildExportArchiveLog string
 	IDEDistrubutionLogsDir     string
+	// XcodebuildAttemptLogPath is the path of the attempt's xcodebuild log saved into the OutputDir
+	XcodebuildAttemptLogPath string
+
+	FreeDiskSpaceMB uint64
 }
 
 // Run ...
@@ -347,16 +817,42 @@ func (s XcodebuildArchiver) Run(opts RunOpts) (RunResult, error) {
 	var (
 		out         = RunResult{}
 		authOptions *xcodebuild.AuthenticationParams
+		outputPaths = outputPathResolver{overwrite: opts.OverwriteOutputs, logger: s.logger}
 	)
 
 	s.logger.Println()
-	if opts.XcodeMajorVersion >= 11 {
+	s.logger.Infof("Checking free disk space")
+	freeDiskSpaceMB, err := s.checkFreeDiskSpace(opts.MinFreeDiskMB, defaultDerivedDataDir(), opts.OutputDir)
+	if err != nil {
+		return out, err
+	}
+	out.FreeDiskSpaceMB = freeDiskSpaceMB
+
+	if opts.PreArchiveScript != "" {
+		if err := s.runPreArchiveScript(opts.PreArchiveScript, opts.ProjectPath, opts.Attempt); err != nil {
+			return out, err
+		}
+	}
+	if opts.ProjectGenerated {
+		if opts.ProjectPath, err = s.validateGeneratedProject(opts.XcodebuildPath, opts.ProjectPath, opts.Scheme); err != nil {
+			return out, err
+		}
+	}
+
+	s.logger.Println()
+	if opts.XcodeMajorVersion >= 11 && !opts.PackageDependenciesResolved {
 		s.logger.Infof("Running resolve Swift package dependencies")
 		// Resolve Swift package dependencies, so running -showBuildSettings later is faster later
 		// Specifying a scheme is required for workspaces
-		resolveDepsCmd := xcodebuild.NewResolvePackagesCommandModel(opts.ProjectPath, opts.Scheme, opts.Configuration)
-		resolveDepsCmd.SetCustomOptions(opts.XcodebuildAdditionalOptions)
-		if err := resolveDepsCmd.Run(); err != nil {
+		err := s.runResolvePackageDependencies(ResolvePackageDependenciesOpts{
+			ProjectPath:                 opts.ProjectPath,
+			Scheme:                      opts.Scheme,
+			ClonedSourcePackagesDirPath: opts.ClonedSourcePackagesDirPath,
+			XcodebuildPath:              opts.XcodebuildPath,
+			Configuration:               opts.Configuration,
+			AdditionalOptions:           opts.XcodebuildAdditionalOptions,
+		}, io.Discard)
+		if err != nil {
 			s.logger.Warnf("%s", err)
 		}
 	}
@@ -367,7 +863,7 @@ func (s XcodebuildArchiver) Run(opts RunOpts) (RunResult, error) {
 		cmdModel := xcodebuild.NewShowBuildSettingsCommand(opts.ProjectPath)
 		cmdModel.SetScheme(opts.Scheme)
 		cmdModel.SetConfiguration(opts.Configuration)
-		settings, err := cmdModel.RunAndReturnSettings()
+		settings, err := runShowBuildSettings(s.cmdFactory, s.logger, opts.XcodebuildPath, cmdModel)
 		if err != nil {
 			return out, fmt.Errorf("failed to read build settings: %w", err)
 		}
@@ -381,9 +877,15 @@ func (s XcodebuildArchiver) Run(opts RunOpts) (RunResult, error) {
 	}
 	out.ArtifactName = opts.ArtifactName
 
-	if opts.CodesignManager != nil {
+	if opts.SkipCodesigning {
+		s.logger.Infof("Code signing is skipped, skipped downloading code sign assets")
+	} else if opts.CodesignManager != nil {
 		s.logger.Infof("Preparing code signing assets (certificates, profiles) before Archive action")
 
+		if err := s.installProvisioningProfiles(opts.CodesignFiles.ProvisioningProfiles, provisioningProfilesDir()); err != nil {
+			return RunResult{}, err
+		}
+
 		xcodebuildAuthParams, err := opts.CodesignManager.PrepareCodesigning()
 		if err != nil {
 			return RunResult{}, fmt.Errorf("failed to manage code signing: %s", err)
@@ -409,124 +911,298 @@ func (s XcodebuildArchiver) Run(opts RunOpts) (RunResult, error) {
 		}
 	} else {
 		s.logger.Infof("Automatic code signing is disabled, skipped downloading code sign assets")
+
+		if opts.KeychainPath != "" && opts.KeychainPassword != "" {
+			s.logger.Println()
+			s.logger.Infof("Preparing keychain for manual code signing")
+
+			keychain := newBuildKeychain(opts.KeychainPath, opts.KeychainPassword, s.cmdFactory, s.pathChecker, s.logger)
+			defer keychain.restore()
+
+			if err := keychain.prepare(); err != nil {
+				return RunResult{}, fmt.Errorf("failed to prepare keychain: %w", err)
+			}
+
+			if opts.CodesignFiles.CertificatePath != "" {
+				s.logger.Printf("Importing certificate: %s", opts.CodesignFiles.CertificatePath)
+				if err := keychain.importCertificate(opts.CodesignFiles.CertificatePath, opts.CodesignFiles.CertificatePassphrase); err != nil {
+					return RunResult{}, fmt.Errorf("failed to import certificate: %w", err)
+				}
+			}
+		}
+
+		if err := s.installProvisioningProfiles(opts.CodesignFiles.ProvisioningProfiles, provisioningProfilesDir()); err != nil {
+			return RunResult{}, err
+		}
+	}
+
+	if opts.AllowProvisioningUpdates && authOptions == nil {
+		s.logger.Warnf("Provisioning updates are allowed (-allowProvisioningUpdates), but no App Store Connect API key is available for xcodebuild, registering devices and updating profiles may fail")
 	}
 	s.logger.Println()
 
+	if len(opts.XcodebuildEnvVars) > 0 {
+		s.logger.Infof("Passing additional environment variables to xcodebuild:")
+		for _, envVar := range opts.XcodebuildEnvVars {
+			if envVar.Secret {
+				s.logger.Printf("- %s (secret)", envVar.Key)
+			} else {
+				s.logger.Printf("- %s", envVar.Key)
+			}
+		}
+		s.logger.Println()
+	}
+	xcodebuildEnvs := envVarsToList(opts.XcodebuildEnvVars)
+
+	if opts.BuildForSimulator {
+		simulatorBuildOut, err := s.xcodeSimulatorBuild(xcodeSimulatorBuildOpts{
+			ProjectPath:   opts.ProjectPath,
+			Scheme:        opts.Scheme,
+			Configuration: opts.Configuration,
+			LogFormatter:  opts.LogFormatter,
+
+			XcodebuildVerbosity:         opts.XcodebuildVerbosity,
+			XcodebuildPath:              opts.XcodebuildPath,
+			BuildParallelism:            opts.BuildParallelism,
+			XcodeMajorVersion:           opts.XcodeMajorVersion,
+			SkipPackagePluginValidation: opts.SkipPackagePluginValidation,
+			SkipMacroValidation:         opts.SkipMacroValidation,
+			HeartbeatInterval:           opts.HeartbeatInterval,
+			HangThreshold:               opts.HangThreshold,
+			HangDiagnosticsDir:          opts.OutputDir,
+			OverwriteOutputs:            opts.OverwriteOutputs,
+			PerformCleanAction:          opts.PerformCleanAction,
+			XcconfigContent:             opts.XcconfigContent,
+			AdditionalOptions:           opts.XcodebuildAdditionalOptions,
+			ClonedSourcePackagesDirPath: opts.ClonedSourcePackagesDirPath,
+			Envs:                        xcodebuildEnvs,
+			Platform:                    opts.Platform,
+		})
+		out.XcodebuildArchiveLog = simulatorBuildOut.XcodebuildBuildLog
+		out.XcodebuildAttemptLogPath = s.saveAttemptLog(outputPaths, opts.OutputDir, opts.Attempt, out.XcodebuildArchiveLog)
+		if err != nil {
+			return out, err
+		}
+
+		out.SimulatorAppPath = simulatorBuildOut.AppPath
+		return out, nil
+	}
+
 	archiveOpts := xcodeArchiveOpts{
-		ProjectPath:       opts.ProjectPath,
-		Scheme:            opts.Scheme,
-		Configuration:     opts.Configuration,
-		LogFormatter:      opts.LogFormatter,
-		XcodeMajorVersion: opts.XcodeMajorVersion,
-		ArtifactName:      opts.ArtifactName,
-		XcodeAuthOptions:  authOptions,
-
-		PerformCleanAction: opts.PerformCleanAction,
-		XcconfigContent:    opts.XcconfigContent,
-		AdditionalOptions:  opts.XcodebuildAdditionalOptions,
-		CacheLevel:         opts.CacheLevel,
+		TempWorkDir:         opts.TempWorkDir,
+		ProjectPath:         opts.ProjectPath,
+		Scheme:              opts.Scheme,
+		Configuration:       opts.Configuration,
+		LogFormatter:        opts.LogFormatter,
+		XcodebuildVerbosity: opts.XcodebuildVerbosity,
+		XcodeMajorVersion:   opts.XcodeMajorVersion,
+		ArtifactName:        opts.ArtifactName,
+		XcodeAuthOptions:    authOptions,
+		HeartbeatInterval:   opts.HeartbeatInterval,
+
+		HangThreshold:      opts.HangThreshold,
+		HangDiagnosticsDir: opts.OutputDir,
+		OverwriteOutputs:   opts.OverwriteOutputs,
+		XcodebuildPath:     opts.XcodebuildPath,
+		BuildParallelism:   opts.BuildParallelism,
+
+		SkipPackagePluginValidation: opts.SkipPackagePluginValidation,
+		SkipMacroValidation:         opts.SkipMacroValidation,
+
+		AllowProvisioningUpdates: opts.AllowProvisioningUpdates,
+		PerformCleanAction:       opts.PerformCleanAction,
+		XcconfigContent:          opts.XcconfigContent,
+		AdditionalOptions:        opts.XcodebuildAdditionalOptions,
+		Destination:              opts.Destination,
+		CacheLevel:               opts.CacheLevel,
+
+		ClonedSourcePackagesDirPath: opts.ClonedSourcePackagesDirPath,
+		Envs:                        xcodebuildEnvs,
+		SkipCodesigning:             opts.SkipCodesigning,
+
+		Platform: opts.Platform,
+		SDK:      opts.SDK,
+	}
+	exportMethodValidated := false
+	if !opts.SkipCodesigning && opts.CustomExportOptionsPlistContent == "" {
+		archiveOpts.ExportMethod = opts.ExportMethod
+
+		s.logger.Infof("Validating export method before Archive action")
+		exportMethodValidated, err = s.validateExportMethodBeforeArchive(validateExportMethodBeforeArchiveOpts{
+			ExportMethod:      opts.ExportMethod,
+			ProjectPath:       opts.ProjectPath,
+			Scheme:            opts.Scheme,
+			Configuration:     opts.Configuration,
+			Platform:          opts.Platform,
+			AdditionalOptions: opts.XcodebuildAdditionalOptions,
+			XcodebuildPath:    opts.XcodebuildPath,
+		})
+		if err != nil {
+			return out, err
+		}
+		s.logger.Println()
 	}
 	archiveOut, err := s.xcodeArchive(archiveOpts)
+	out.ArchivePath = archiveOut.ArchivePath
+	out.Platform = archiveOut.Platform
 	out.XcodebuildArchiveLog = archiveOut.XcodebuildArchiveLog
+	out.XcodebuildAttemptLogPath = s.saveAttemptLog(outputPaths, opts.OutputDir, opts.Attempt, out.XcodebuildArchiveLog)
 	if err != nil {
+		s.printCodesignHints(out.XcodebuildArchiveLog)
 		return out, err
 	}
 
 	out.Archive = archiveOut.Archive
 
-	IPAExportOpts := xcodeIPAExportOpts{
-		ProjectPath:       opts.ProjectPath,
-		Scheme:            opts.Scheme,
-		Configuration:     opts.Configuration,
-		LogFormatter:      opts.LogFormatter,
-		XcodeMajorVersion: opts.XcodeMajorVersion,
-		XcodeAuthOptions:  authOptions,
+	if opts.SkipCodesigning {
+		s.logger.Println()
+		s.logger.Infof("Code signing is skipped, skipping the IPA export")
+		out.UnsignedArchivePath = archiveOut.ArchivePath
+		return out, nil
+	}
+
+	if !exportMethodValidated && opts.CustomExportOptionsPlistContent == "" {
+		if err := s.validateExportMethodForArchive(opts.ExportMethod, *archiveOut.Archive); err != nil {
+			return out, err
+		}
+	}
 
+	IPAExportOpts := xcodeIPAExportOpts{
+		TempWorkDir:         opts.TempWorkDir,
+		ProjectPath:         opts.ProjectPath,
+		Scheme:              opts.Scheme,
+		Configuration:       opts.Configuration,
+		LogFormatter:        opts.LogFormatter,
+		XcodebuildVerbosity: opts.XcodebuildVerbosity,
+		XcodeMajorVersion:   opts.XcodeMajorVersion,
+		XcodeAuthOptions:    authOptions,
+		XcodebuildPath:      opts.XcodebuildPath,
+
+		AllowProvisioningUpdates:        opts.AllowProvisioningUpdates,
+		Envs:                            xcodebuildEnvs,
 		Archive:                         *archiveOut.Archive,
 		CustomExportOptionsPlistContent: opts.CustomExportOptionsPlistContent,
 		ExportMethod:                    opts.ExportMethod,
 		ICloudContainerEnvironment:      opts.ICloudContainerEnvironment,
 		ExportDevelopmentTeam:           opts.ExportDevelopmentTeam,
+		Thinning:                        opts.Thinning,
+		OTAManifest:                     opts.OTAManifest,
 		UploadBitcode:                   opts.UploadBitcode,
 		CompileBitcode:                  opts.CompileBitcode,
 	}
 	exportOut, err := s.xcodeIPAExport(IPAExportOpts)
 	out.XcodebuildExportArchiveLog = exportOut.XcodebuildExportArchiveLog
+	out.ExportOptionsPath = exportOut.ExportOptionsPath
 	if err != nil {
 		out.IDEDistrubutionLogsDir = exportOut.IDEDistrubutionLogsDir
+		s.printCodesignHints(out.XcodebuildExportArchiveLog)
 		return out, err
 	}
 
-	out.ExportOptionsPath = exportOut.ExportOptionsPath
 	out.IPAExportDir = exportOut.IPAExportDir
 
+	out.AdditionalIPAExports, out.AdditionalExportErr = s.exportAdditionalIPAs(IPAExportOpts, opts.AdditionalExportMethods, opts.AdditionalExportCodesignManagers, opts.FailOnAdditionalExportError)
+
 	return out, nil
 }
 
 // ExportOpts ...
 type ExportOpts struct {
-	OutputDir      string
-	ArtifactName   string
-	ExportAllDsyms bool
-
-	Archive *xcarchive.IosArchive
-
-	ExportOptionsPath string
-	IPAExportDir      string
+	OutputDir        string
+	ArtifactName     string
+	Scheme           string
+	ExportAllDsyms   bool
+	ExportNestedApps bool
+	TempWorkDir      string
+	UploadBitcode    bool
+	CompileBitcode   bool
+
+	Archive             *xcarchive.IosArchive
+	UnsignedArchivePath string
+	SimulatorAppPath    string
+
+	ExportOptionsPath    string
+	IPAExportDir         string
+	AdditionalIPAExports []AdditionalIPAExport
 
 	XcodebuildArchiveLog       string
 	XcodebuildExportArchiveLog string
 	IDEDistrubutionLogsDir     string
+	XcodebuildAttemptLogPaths  []string
+	// SucceededAttempt is the 1-based index of the successful archive attempt, 0 if every attempt failed
+	SucceededAttempt int
+
+	OutputDirStrategy string
+	OverwriteOutputs  bool
+}
+
+// ExportResult ...
+type ExportResult struct {
+	IPAPath         string
+	DSYMDir         string
+	AppVersion      *AppVersion
+	Warnings        []string
+	ThinnedVariants []ThinnedVariant
 }
 
 // ExportOutput ...
-func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
+func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) (ExportResult, error) {
 	s.logger.Println()
 	s.logger.Infof("Exporting outputs...")
 
-	cleanup := func(pth string) error {
-		if exist, err := v1pathutil.IsPathExists(pth); err != nil {
-			return fmt.Errorf("failed to check if path (%s) exist, error: %s", pth, err)
-		} else if exist {
-			if err := os.RemoveAll(pth); err != nil {
-				return fmt.Errorf("failed to remove path (%s), error: %s", pth, err)
-			}
+	var out ExportResult
+	outputPaths := outputPathResolver{overwrite: opts.OverwriteOutputs, logger: s.logger}
+
+	if opts.OutputDirStrategy == outputDirStrategyPerRun {
+		if err := exportEnvironmentWithEnvman(s.cmdFactory, bitriseOutputDirPthEnvKey, opts.OutputDir); err != nil {
+			return out, fmt.Errorf("failed to export %s, error: %s", bitriseOutputDirPthEnvKey, err)
 		}
-		return nil
+		s.logger.Donef("The output directory of this run is now available in the Environment Variable: %s (value: %s)", bitriseOutputDirPthEnvKey, opts.OutputDir)
 	}
 
 	if opts.Archive != nil {
 		archivePath := opts.Archive.Path
-		if err := ExportOutputDir(s.cmdFactory, archivePath, archivePath, bitriseXCArchivePthEnvKey, s.logger); err != nil {
-			return fmt.Errorf("failed to export %s, error: %s", bitriseXCArchivePthEnvKey, err)
+		// the archive is created in the temp working directory, which might be removed at the end of the run
+		archiveOutputPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, opts.ArtifactName+".xcarchive"))
+		if err != nil {
+			return out, err
 		}
-		s.logger.Donef("The xcarchive path is now available in the Environment Variable: %s (value: %s)", bitriseXCArchivePthEnvKey, archivePath)
+		if err := ExportOutputDir(s.cmdFactory, archivePath, archiveOutputPath, bitriseXCArchivePthEnvKey, s.logger); err != nil {
+			return out, fmt.Errorf("failed to export %s, error: %s", bitriseXCArchivePthEnvKey, err)
+		}
+		s.logger.Donef("The xcarchive path is now available in the Environment Variable: %s (value: %s)", bitriseXCArchivePthEnvKey, archiveOutputPath)
 
-		archiveZipPath := filepath.Join(opts.OutputDir, opts.ArtifactName+".xcarchive.zip")
-		if err := cleanup(archiveZipPath); err != nil {
-			return err
+		archiveZipPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, opts.ArtifactName+".xcarchive.zip"))
+		if err != nil {
+			return out, err
 		}
 
 		if err := ExportOutputDirAsZip(s.cmdFactory, archivePath, archiveZipPath, bitriseXCArchiveZipPthEnvKey, s.logger); err != nil {
-			return fmt.Errorf("failed to export %s, error: %s", bitriseXCArchiveZipPthEnvKey, err)
+			return out, fmt.Errorf("failed to export %s, error: %s", bitriseXCArchiveZipPthEnvKey, err)
 		}
 		s.logger.Donef("The xcarchive zip path is now available in the Environment Variable: %s (value: %s)", bitriseXCArchiveZipPthEnvKey, archiveZipPath)
 
-		appPath := filepath.Join(opts.OutputDir, opts.ArtifactName+".app")
-		if err := cleanup(appPath); err != nil {
-			return err
+		appPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, opts.ArtifactName+".app"))
+		if err != nil {
+			return out, err
 		}
 
 		if err := ExportOutputDir(s.cmdFactory, opts.Archive.Application.Path, appPath, bitriseAppDirPthEnvKey, s.logger); err != nil {
-			return fmt.Errorf("failed to export %s, error: %s", bitriseAppDirPthEnvKey, err)
+			return out, fmt.Errorf("failed to export %s, error: %s", bitriseAppDirPthEnvKey, err)
 		}
 		s.logger.Donef("The app directory is now available in the Environment Variable: %s (value: %s)", bitriseAppDirPthEnvKey, appPath)
 
+		if opts.ExportNestedApps {
+			if err := s.exportNestedApps(opts.Archive.Application.Path, opts.OutputDir, opts.ArtifactName, outputPaths); err != nil {
+				return out, err
+			}
+		}
+
 		s.logger.Printf("Looking for app and framework dSYMs.")
 
 		appDSYMPaths, frameworkDSYMPaths, err := opts.Archive.FindDSYMs()
 		if err != nil {
-			return fmt.Errorf("failed to export dSYMs, error: %s", err)
+			return out, fmt.Errorf("failed to export dSYMs, error: %s", err)
 		}
 
 		appDSYMPathsCount := len(appDSYMPaths)
@@ -535,14 +1211,14 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 		s.logger.Printf("Found %d app dSYMs and %d framework dSYMs.", appDSYMPathsCount, frameworkDSYMPathsCount)
 
 		if appDSYMPathsCount > 0 || frameworkDSYMPathsCount > 0 {
-			dsymDir, err := v1pathutil.NormalizedOSTempDirPath("__dsyms__")
+			dsymDir, err := newTempDir(opts.TempWorkDir, "__dsyms__")
 			if err != nil {
-				return fmt.Errorf("failed to create tmp dir, error: %s", err)
+				return out, fmt.Errorf("failed to create tmp dir, error: %s", err)
 			}
 
 			if appDSYMPathsCount > 0 {
 				if err := ExportDSYMs(dsymDir, appDSYMPaths); err != nil {
-					return fmt.Errorf("failed to export dSYMs: %v", err)
+					return out, fmt.Errorf("failed to export dSYMs: %v", err)
 				}
 			} else {
 				s.logger.Warnf("No app dSYMs found to export")
@@ -550,36 +1226,81 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 
 			if opts.ExportAllDsyms && frameworkDSYMPathsCount > 0 {
 				if err := ExportDSYMs(dsymDir, frameworkDSYMPaths); err != nil {
-					return fmt.Errorf("failed to export dSYMs: %v", err)
+					return out, fmt.Errorf("failed to export dSYMs: %v", err)
 				}
 			}
 
-			if err := ExportOutputDir(s.cmdFactory, dsymDir, dsymDir, bitriseDSYMDirPthEnvKey, s.logger); err != nil {
-				return fmt.Errorf("failed to export %s, error: %s", bitriseDSYMDirPthEnvKey, err)
+			dsymOutputDir, err := outputPaths.resolve(filepath.Join(opts.OutputDir, opts.ArtifactName+".dSYMs"))
+			if err != nil {
+				return out, err
 			}
-			s.logger.Donef("The dSYM dir path is now available in the Environment Variable: %s (value: %s)", bitriseDSYMDirPthEnvKey, dsymDir)
+			if err := ExportOutputDir(s.cmdFactory, dsymDir, dsymOutputDir, bitriseDSYMDirPthEnvKey, s.logger); err != nil {
+				return out, fmt.Errorf("failed to export %s, error: %s", bitriseDSYMDirPthEnvKey, err)
+			}
+			s.logger.Donef("The dSYM dir path is now available in the Environment Variable: %s (value: %s)", bitriseDSYMDirPthEnvKey, dsymOutputDir)
+			out.DSYMDir = dsymOutputDir
 
-			dsymZipPath := filepath.Join(opts.OutputDir, opts.ArtifactName+".dSYM.zip")
-			if err := cleanup(dsymZipPath); err != nil {
-				return err
+			dsymZipPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, opts.ArtifactName+".dSYM.zip"))
+			if err != nil {
+				return out, err
 			}
 
 			if err := ExportOutputDirAsZip(s.cmdFactory, dsymDir, dsymZipPath, bitriseDSYMPthEnvKey, s.logger); err != nil {
-				return fmt.Errorf("failed to export %s, error: %s", bitriseDSYMPthEnvKey, err)
+				return out, fmt.Errorf("failed to export %s, error: %s", bitriseDSYMPthEnvKey, err)
 			}
 			s.logger.Donef("The dSYM zip path is now available in the Environment Variable: %s (value: %s)", bitriseDSYMPthEnvKey, dsymZipPath)
 		}
+
+		if opts.UploadBitcode || opts.CompileBitcode {
+			bcSymbolMapsDir := filepath.Join(archivePath, "BCSymbolMaps")
+			if exist, err := v1pathutil.IsDirExists(bcSymbolMapsDir); err != nil {
+				return out, fmt.Errorf("failed to check if BCSymbolMaps dir exist, error: %s", err)
+			} else if !exist {
+				s.logger.Printf("No BCSymbolMaps found in the archive (non-bitcode build), skipping export")
+			} else {
+				bcSymbolMapsZipPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, opts.ArtifactName+".bcsymbolmaps.zip"))
+				if err != nil {
+					return out, err
+				}
+
+				if err := ExportOutputDirAsZip(s.cmdFactory, bcSymbolMapsDir, bcSymbolMapsZipPath, bitriseBCSymbolMapsPthEnvKey, s.logger); err != nil {
+					return out, fmt.Errorf("failed to export %s, error: %s", bitriseBCSymbolMapsPthEnvKey, err)
+				}
+				s.logger.Donef("The BCSymbolMaps zip path is now available in the Environment Variable: %s (value: %s)", bitriseBCSymbolMapsPthEnvKey, bcSymbolMapsZipPath)
+			}
+		}
+	}
+
+	if opts.UnsignedArchivePath != "" {
+		if err := s.exportUnsignedArchive(opts.UnsignedArchivePath, opts.OutputDir, opts.ArtifactName, outputPaths); err != nil {
+			return out, err
+		}
+	}
+
+	archivePath := opts.UnsignedArchivePath
+	if opts.Archive != nil {
+		archivePath = opts.Archive.Path
+	}
+	if archivePath != "" {
+		out.AppVersion = s.exportAppVersion(archivePath, opts.Scheme)
+	}
+
+	if opts.SimulatorAppPath != "" {
+		if err := s.exportSimulatorApp(opts.SimulatorAppPath, opts.OutputDir, opts.ArtifactName, outputPaths); err != nil {
+			return out, err
+		}
 	}
 
 	if opts.ExportOptionsPath != "" {
-		exportOptionsPath := filepath.Join(opts.OutputDir, "export_options.plist")
-		if err := cleanup(exportOptionsPath); err != nil {
-			return err
+		exportOptionsPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, "export_options.plist"))
+		if err != nil {
+			return out, err
 		}
 
-		if err := v1command.CopyFile(opts.ExportOptionsPath, exportOptionsPath); err != nil {
-			return err
+		if err := ExportOutputFile(s.cmdFactory, opts.ExportOptionsPath, exportOptionsPath, bitriseExportOptionsPthEnvKey); err != nil {
+			return out, fmt.Errorf("failed to export %s, error: %s", bitriseExportOptionsPthEnvKey, err)
 		}
+		s.logger.Donef("The export options plist path is now available in the Environment Variable: %s (value: %s)", bitriseExportOptionsPthEnvKey, exportOptionsPath)
 	}
 
 	if opts.IPAExportDir != "" {
@@ -598,7 +1319,7 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 
 			return nil
 		}); walkErr != nil {
-			return fmt.Errorf("failed to search for .ipa file, error: %s", walkErr)
+			return out, fmt.Errorf("failed to search for .ipa file, error: %s", walkErr)
 		}
 
 		if len(ipaFiles) == 0 {
@@ -606,18 +1327,19 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 			for _, pth := range fileList {
 				s.logger.Printf("- %s", pth)
 			}
-			return fmt.Errorf("No .ipa file found at export dir: %s", opts.IPAExportDir)
+			return out, fmt.Errorf("No .ipa file found at export dir: %s", opts.IPAExportDir)
 		}
 
-		ipaPath := filepath.Join(opts.OutputDir, opts.ArtifactName+".ipa")
-		if err := cleanup(ipaPath); err != nil {
-			return err
+		ipaPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, opts.ArtifactName+".ipa"))
+		if err != nil {
+			return out, err
 		}
 
 		if err := ExportOutputFile(s.cmdFactory, ipaFiles[0], ipaPath, bitriseIPAPthEnvKey); err != nil {
-			return fmt.Errorf("failed to export %s, error: %s", bitriseIPAPthEnvKey, err)
+			return out, fmt.Errorf("failed to export %s, error: %s", bitriseIPAPthEnvKey, err)
 		}
 		s.logger.Donef("The ipa path is now available in the Environment Variable: %s (value: %s)", bitriseIPAPthEnvKey, ipaPath)
+		out.IPAPath = ipaPath
 
 		if len(ipaFiles) > 1 {
 			s.logger.Warnf("More than 1 .ipa file found, exporting first one: %s", ipaFiles[0])
@@ -629,19 +1351,35 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 				}
 
 				base := filepath.Base(pth)
-				deployPth := filepath.Join(opts.OutputDir, base)
+				deployPth, err := outputPaths.resolve(filepath.Join(opts.OutputDir, base))
+				if err != nil {
+					return out, err
+				}
 
 				if err := v1command.CopyFile(pth, deployPth); err != nil {
-					return fmt.Errorf("failed to copy (%s) -> (%s), error: %s", pth, deployPth, err)
+					return out, fmt.Errorf("failed to copy (%s) -> (%s), error: %s", pth, deployPth, err)
 				}
 			}
 		}
+
+		out.ThinnedVariants, err = s.exportAppThinningSizeReport(opts.IPAExportDir, opts.OutputDir, outputPaths)
+		if err != nil {
+			return out, err
+		}
+
+		if err := s.exportOTAManifest(opts.IPAExportDir, opts.OutputDir, outputPaths); err != nil {
+			return out, err
+		}
+	}
+
+	if err := s.exportAdditionalIPAOutputs(opts.AdditionalIPAExports, opts.OutputDir, opts.ArtifactName, outputPaths); err != nil {
+		return out, err
 	}
 
 	if opts.IDEDistrubutionLogsDir != "" {
-		ideDistributionLogsZipPath := filepath.Join(opts.OutputDir, "xcodebuild.xcdistributionlogs.zip")
-		if err := cleanup(ideDistributionLogsZipPath); err != nil {
-			return err
+		ideDistributionLogsZipPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, "xcodebuild.xcdistributionlogs.zip"))
+		if err != nil {
+			return out, err
 		}
 
 		if err := ExportOutputDirAsZip(s.cmdFactory, opts.IDEDistrubutionLogsDir, ideDistributionLogsZipPath, bitriseIDEDistributionLogsPthEnvKey, s.logger); err != nil {
@@ -652,9 +1390,9 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 	}
 
 	if opts.XcodebuildArchiveLog != "" {
-		xcodebuildArchiveLogPath := filepath.Join(opts.OutputDir, xcodebuildArchiveLogFilename)
-		if err := cleanup(xcodebuildArchiveLogPath); err != nil {
-			return err
+		xcodebuildArchiveLogPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, xcodebuildArchiveLogFilename))
+		if err != nil {
+			return out, err
 		}
 
 		if err := ExportOutputFileContent(s.cmdFactory, opts.XcodebuildArchiveLog, xcodebuildArchiveLogPath, xcodebuildArchiveLogPathEnvKey); err != nil {
@@ -662,12 +1400,23 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 		} else {
 			s.logger.Donef("The xcodebuild archive log path is now available in the Environment Variable: %s (value: %s)", xcodebuildArchiveLogPathEnvKey, xcodebuildArchiveLogPath)
 		}
+
+		out.Warnings = findXcodebuildWarnings(opts.XcodebuildArchiveLog)
+		warningCount := strconv.Itoa(len(out.Warnings))
+		if err := exportEnvironmentWithEnvman(s.cmdFactory, buildWarningCountEnvKey, warningCount); err != nil {
+			s.logger.Warnf("Failed to export %s, error: %s", buildWarningCountEnvKey, err)
+		} else {
+			s.logger.Donef("The number of distinct build warnings is now available in the Environment Variable: %s (value: %s)", buildWarningCountEnvKey, warningCount)
+		}
 	}
 
+	s.exportAttemptLogs(opts.XcodebuildAttemptLogPaths)
+	s.exportArchiveAttempts(opts.SucceededAttempt)
+
 	if opts.XcodebuildExportArchiveLog != "" {
-		xcodebuildExportArchiveLogPath := filepath.Join(opts.OutputDir, xcodebuildExportArchiveLogFilename)
-		if err := cleanup(xcodebuildExportArchiveLogPath); err != nil {
-			return err
+		xcodebuildExportArchiveLogPath, err := outputPaths.resolve(filepath.Join(opts.OutputDir, xcodebuildExportArchiveLogFilename))
+		if err != nil {
+			return out, err
 		}
 
 		if err := ExportOutputFileContent(s.cmdFactory, opts.XcodebuildExportArchiveLog, xcodebuildExportArchiveLogPath, xcodebuildExportArchiveLogPathEnvKey); err != nil {
@@ -677,7 +1426,7 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 		}
 	}
 
-	return nil
+	return out, nil
 }
 
 func (s XcodebuildArchiver) createCodesignManager(config Config) (codesign.Manager, error) {
@@ -691,11 +1440,12 @@ func (s XcodebuildArchiver) createCodesignManager(config Config) (codesign.Manag
 		return codesign.Manager{}, fmt.Errorf("automatic code signing is disabled")
 	}
 
+	certificateURLList, certificatePassphraseList := config.CodesignFiles.certificateURLs(config.CertificateURLList, config.CertificatePassphraseList)
 	codesignInputs := codesign.Input{
 		AuthType:                     authType,
 		DistributionMethod:           config.ExportMethod,
-		CertificateURLList:           config.CertificateURLList,
-		CertificatePassphraseList:    config.CertificatePassphraseList,
+		CertificateURLList:           certificateURLList,
+		CertificatePassphraseList:    certificatePassphraseList,
 		KeychainPath:                 config.KeychainPath,
 		KeychainPassword:             config.KeychainPassword,
 		FallbackProvisioningProfiles: config.FallbackProvisioningProfileURLs,
@@ -774,23 +1524,47 @@ func (s XcodebuildArchiver) createCodesignManager(config Config) (codesign.Manag
 }
 
 type xcodeArchiveOpts struct {
-	ProjectPath       string
-	Scheme            string
-	Configuration     string
-	LogFormatter      string
-	XcodeMajorVersion int
-	ArtifactName      string
-	XcodeAuthOptions  *xcodebuild.AuthenticationParams
-
-	PerformCleanAction bool
-	XcconfigContent    string
-	AdditionalOptions  []string
-
-	CacheLevel string
+	TempWorkDir         string
+	ProjectPath         string
+	Scheme              string
+	Configuration       string
+	LogFormatter        string
+	XcodebuildVerbosity string
+	XcodeMajorVersion   int
+	ArtifactName        string
+	XcodeAuthOptions    *xcodebuild.AuthenticationParams
+	HeartbeatInterval   time.Duration
+
+	HangThreshold      time.Duration
+	HangDiagnosticsDir string
+	OverwriteOutputs   bool
+	XcodebuildPath     string
+	BuildParallelism   int
+
+	SkipPackagePluginValidation bool
+	SkipMacroValidation         bool
+
+	AllowProvisioningUpdates bool
+	PerformCleanAction       bool
+	XcconfigContent          string
+	AdditionalOptions        []string
+	Destination              string
+
+	CacheLevel                  CacheLevel
+	ClonedSourcePackagesDirPath string
+
+	Envs            []string
+	SkipCodesigning bool
+
+	Platform     Platform // empty if detected from the project
+	SDK          string
+	ExportMethod string // used to validate the detected platform, empty if not exporting an IPA
 }
 
 type xcodeArchiveResult struct {
 	Archive              *xcarchive.IosArchive
+	ArchivePath          string
+	Platform             Platform
 	XcodebuildArchiveLog string
 }
 
@@ -807,10 +1581,19 @@ func (s XcodebuildArchiver) xcodeArchive(opts xcodeArchiveOpts) (xcodeArchiveRes
 
 	s.logger.TInfof("Reading xcode project")
 
-	platform, err := BuildableTargetPlatform(xcodeProj, scheme, configuration, opts.AdditionalOptions, XcodeBuild{}, s.logger)
-	if err != nil {
-		return out, fmt.Errorf("failed to read project platform: %s: %s", opts.ProjectPath, err)
+	platform := opts.Platform
+	if platform != "" {
+		s.logger.Printf("Platform type: %s (set by the Platform input)", platform)
+	} else {
+		platform, err = BuildableTargetPlatform(xcodeProj, scheme, configuration, opts.AdditionalOptions, XcodeBuild{XcodebuildPath: opts.XcodebuildPath, CmdFactory: s.cmdFactory, Logger: s.logger}, s.logger)
+		if err != nil {
+			return out, fmt.Errorf("failed to read project platform: %s: %s", opts.ProjectPath, err)
+		}
+		if err := validatePlatform(platform, opts.ExportMethod, opts.XcodeMajorVersion); err != nil {
+			return out, err
+		}
 	}
+	out.Platform = platform
 
 	s.logger.TInfof("Reading main target")
 
@@ -849,19 +1632,36 @@ and use 'Export iOS and tvOS Xcode archive' step to export an App Clip.`, opts.S
 		archiveCmd.SetXCConfigPath(xcconfigPath)
 	}
 
-	tmpDir, err := v1pathutil.NormalizedOSTempDirPath("xcodeArchive")
+	tmpDir, err := newTempDir(opts.TempWorkDir, "xcodeArchive")
 	if err != nil {
 		return out, fmt.Errorf("failed to create temp dir, error: %s", err)
 	}
 	archivePth := filepath.Join(tmpDir, opts.ArtifactName+".xcarchive")
+	out.ArchivePath = archivePth
 
 	archiveCmd.SetArchivePath(archivePth)
-	if opts.XcodeAuthOptions != nil {
+	if opts.XcodeAuthOptions != nil && opts.AllowProvisioningUpdates {
 		archiveCmd.SetAuthentication(*opts.XcodeAuthOptions)
 	}
 
-	additionalOptions := generateAdditionalOptions(string(platform), opts.AdditionalOptions)
+	customOptions := opts.AdditionalOptions
+	if opts.Destination != "" {
+		customOptions = append([]string{"-destination", opts.Destination}, customOptions...)
+	}
+	additionalOptions := generateAdditionalOptions(string(platform), customOptions)
+	if opts.SDK != "" {
+		additionalOptions = append(additionalOptions, "-sdk", opts.SDK)
+	}
+	additionalOptions = append(additionalOptions, buildParallelismArgs(opts.BuildParallelism)...)
+	additionalOptions = append(additionalOptions, xcodebuildVerbosityArgs(opts.XcodebuildVerbosity, opts.LogFormatter)...)
+	additionalOptions = append(additionalOptions, packageValidationArgs(opts.SkipPackagePluginValidation, opts.SkipMacroValidation, opts.XcodeMajorVersion, s.logger)...)
+	additionalOptions = append(additionalOptions, clonedSourcePackagesDirArgs(opts.ClonedSourcePackagesDirPath)...)
+	additionalOptions = append(additionalOptions, allowProvisioningUpdatesArgs(opts.AllowProvisioningUpdates, opts.XcodeAuthOptions)...)
+	if opts.SkipCodesigning {
+		additionalOptions = append(additionalOptions, "CODE_SIGNING_ALLOWED=NO", "CODE_SIGNING_REQUIRED=NO")
+	}
 	archiveCmd.SetCustomOptions(additionalOptions)
+	archiveCmdModel := newXcodebuildCommand(archiveCmd, opts.XcodebuildPath, nil, opts.Envs)
 
 	var swiftPackagesPath string
 	if opts.XcodeMajorVersion >= 11 {
@@ -871,9 +1671,17 @@ and use 'Export iOS and tvOS Xcode archive' step to export an App Clip.`, opts.S
 		}
 	}
 
+	logBuildParallelism(opts.BuildParallelism, s.logger)
 	s.logger.Infof("Starting the Archive ...")
 
-	xcodebuildLog, err := runArchiveCommandWithRetry(archiveCmd, opts.LogFormatter == "xcpretty", swiftPackagesPath, s.logger)
+	xcodebuildLog, err := runArchiveCommandWithRetry(archiveCmdModel, opts.LogFormatter == "xcpretty", swiftPackagesPath, archiveMonitorOpts{
+		HeartbeatInterval:  opts.HeartbeatInterval,
+		HangThreshold:      opts.HangThreshold,
+		HangDiagnosticsDir: opts.HangDiagnosticsDir,
+		CommandFactory:     s.cmdFactory,
+		OverwriteOutputs:   opts.OverwriteOutputs,
+		LiveLogPath:        filepath.Join(tmpDir, xcodebuildArchiveLiveLogFilename),
+	}, s.logger)
 	out.XcodebuildArchiveLog = xcodebuildLog
 	if err != nil || opts.LogFormatter == "xcodebuild" {
 		const lastLinesMsg = "\nLast lines of the Xcode's build log:"
@@ -898,6 +1706,18 @@ The log file will be stored in $BITRISE_DEPLOY_DIR, and its full path will be av
 		return out, fmt.Errorf("no archive generated at: %s", archivePth)
 	}
 
+	// Cache swift PM and DerivedData
+	if opts.XcodeMajorVersion >= 11 {
+		if err := s.collectCache(opts.CacheLevel, opts.ProjectPath, opts.ClonedSourcePackagesDirPath); err != nil {
+			s.logger.Warnf("Failed to mark build folders for caching, error: %s", err)
+		}
+	}
+
+	if opts.SkipCodesigning {
+		// The unsigned archive's app has no embedded provisioning profile, it can not be parsed as an iOS archive
+		return out, nil
+	}
+
 	archive, err := xcarchive.NewIosArchive(archivePth)
 	if err != nil {
 		return out, fmt.Errorf("failed to parse archive, error: %s", err)
@@ -913,29 +1733,29 @@ The log file will be stored in $BITRISE_DEPLOY_DIR, and its full path will be av
 	s.logger.Printf("export: %s", mainApplication.ProvisioningProfile.ExportType)
 	s.logger.Printf("xcode managed profile: %v", profileutil.IsXcodeManaged(mainApplication.ProvisioningProfile.Name))
 
-	// Cache swift PM
-	if opts.XcodeMajorVersion >= 11 && opts.CacheLevel == "swift_packages" {
-		if err := cache.NewSwiftPackageCache().CollectSwiftPackages(opts.ProjectPath); err != nil {
-			s.logger.Warnf("Failed to mark swift packages for caching, error: %s", err)
-		}
-	}
-
 	return out, nil
 }
 
 type xcodeIPAExportOpts struct {
-	ProjectPath       string
-	Scheme            string
-	Configuration     string
-	LogFormatter      string
-	XcodeMajorVersion int
-	XcodeAuthOptions  *xcodebuild.AuthenticationParams
-
+	TempWorkDir         string
+	ProjectPath         string
+	Scheme              string
+	Configuration       string
+	LogFormatter        string
+	XcodebuildVerbosity string
+	XcodeMajorVersion   int
+	XcodeAuthOptions    *xcodebuild.AuthenticationParams
+	XcodebuildPath      string
+
+	AllowProvisioningUpdates        bool
+	Envs                            []string
 	Archive                         xcarchive.IosArchive
 	CustomExportOptionsPlistContent string
 	ExportMethod                    string
 	ICloudContainerEnvironment      string
 	ExportDevelopmentTeam           string
+	Thinning                        string
+	OTAManifest                     exportoptions.Manifest
 	UploadBitcode                   bool
 	CompileBitcode                  bool
 }
@@ -971,7 +1791,7 @@ func (s XcodebuildArchiver) xcodeIPAExport(opts xcodeIPAExportOpts) (xcodeIPAExp
 	s.logger.Println()
 	s.logger.Infof("Exporting ipa from the archive...")
 
-	tmpDir, err := v1pathutil.NormalizedOSTempDirPath("xcodeIPAExport")
+	tmpDir, err := newTempDir(opts.TempWorkDir, "xcodeIPAExport")
 	if err != nil {
 		return out, fmt.Errorf("failed to create temp dir, error: %s", err)
 	}
@@ -1016,6 +1836,9 @@ func (s XcodebuildArchiver) xcodeIPAExport(opts xcodeIPAExportOpts) (xcodeIPAExp
 		}
 
 		s.logger.Println()
+		exportOptions = applyThinning(exportOptions, opts.Thinning)
+		exportOptions = applyOTAManifest(exportOptions, opts.OTAManifest)
+
 		s.logger.Printf("generated export options content:")
 		s.logger.Println()
 		s.logger.Printf(exportOptions.String())
@@ -1031,12 +1854,17 @@ func (s XcodebuildArchiver) xcodeIPAExport(opts xcodeIPAExportOpts) (xcodeIPAExp
 	exportCmd.SetArchivePath(opts.Archive.Path)
 	exportCmd.SetExportDir(ipaExportDir)
 	exportCmd.SetExportOptionsPlist(exportOptionsPath)
-	if opts.XcodeAuthOptions != nil {
+	// exposed even if the export fails, to help debugging the export
+	out.ExportOptionsPath = exportOptionsPath
+	if opts.XcodeAuthOptions != nil && opts.AllowProvisioningUpdates {
 		exportCmd.SetAuthentication(*opts.XcodeAuthOptions)
 	}
+	exportArgs := allowProvisioningUpdatesArgs(opts.AllowProvisioningUpdates, opts.XcodeAuthOptions)
+	exportArgs = append(exportArgs, xcodebuildVerbosityArgs(opts.XcodebuildVerbosity, opts.LogFormatter)...)
+	exportCmdModel := newXcodebuildCommand(exportCmd, opts.XcodebuildPath, exportArgs, opts.Envs)
 
 	useXCPretty := opts.LogFormatter == "xcpretty"
-	xcodebuildLog, exportErr := runIPAExportCommand(exportCmd, useXCPretty, s.logger)
+	xcodebuildLog, exportErr := runIPAExportCommand(exportCmdModel, useXCPretty, s.logger)
 	out.XcodebuildExportArchiveLog = xcodebuildLog
 	if exportErr != nil {
 		if useXCPretty {
@@ -1072,7 +1900,6 @@ is available in the $BITRISE_IDEDISTRIBUTION_LOGS_PATH environment variable`)
 		return out, fmt.Errorf("failed to export IPA: %w", exportErr)
 	}
 
-	out.ExportOptionsPath = exportOptionsPath
 	out.IPAExportDir = ipaExportDir
 
 	return out, nil
@@ -2,24 +2,31 @@ package step
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
 
 	"github.com/bitrise-io/go-steputils/v2/stepconf"
 	"github.com/bitrise-io/go-utils/fileutil"
+	v2fileutil "github.com/bitrise-io/go-utils/v2/fileutil"
 	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-utils/v2/pathutil"
 	"github.com/bitrise-io/go-xcode/models"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 )
 
 func TestXcodeArchiveStep_ProcessInputs(t *testing.T) {
+	projectPath := filepath.Join(t.TempDir(), "Sample.xcodeproj")
+	require.NoError(t, os.MkdirAll(projectPath, 0755))
+
 	tests := []struct {
-		name string
-		envs map[string]string
-		want Config
-		err  string
+		name              string
+		envs              map[string]string
+		xcodeMajorVersion int64
+		want              Config
+		err               string
 	}{
 		{
 			name: "project_path should be and .xcodeproj or .xcworkspace path",
@@ -31,13 +38,150 @@ func TestXcodeArchiveStep_ProcessInputs(t *testing.T) {
 			want: Config{},
 			err:  "issue with input ProjectPath: should be and .xcodeproj or .xcworkspace path",
 		},
+		{
+			name: "destination should be a known generic destination",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path": ".",
+				"scheme":       "My Scheme",
+				"destination":  "generic/platform=iOSS",
+			}),
+			want: Config{},
+			err:  "issue with input Destination: unknown destination (generic/platform=iOSS)",
+		},
+		{
+			name: "macOS platform can not be exported",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path":        projectPath,
+				"scheme":              "My Scheme",
+				"platform":            "macos",
+				"distribution_method": "app-store",
+			}),
+			xcodeMajorVersion: 15,
+			want:              Config{},
+			err:               "issue with input Platform: macOS apps are distributed as notarized (Developer ID) or Mac App Store packages, which this Step does not export (distribution method: app-store), please use a macOS archive Step instead",
+		},
+		{
+			name: "watchOS platform can not be exported with enterprise method",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path":        projectPath,
+				"scheme":              "My Scheme",
+				"platform":            "watchos",
+				"distribution_method": "enterprise",
+			}),
+			xcodeMajorVersion: 15,
+			want:              Config{},
+			err:               "issue with input Platform: enterprise distribution method is not available for watchOS apps, available methods: app-store, ad-hoc, development",
+		},
+		{
+			name: "visionOS platform requires Xcode 15",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path": projectPath,
+				"scheme":       "My Scheme",
+				"platform":     "visionos",
+			}),
+			xcodeMajorVersion: 14,
+			want:              Config{},
+			err:               "issue with input Platform: visionOS apps require Xcode 15 or later, current Xcode major version: 14",
+		},
+		{
+			name: "platform sdk conflicts with -sdk xcodebuild option",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path":       projectPath,
+				"scheme":             "My Scheme",
+				"platform":           "tvos",
+				"xcodebuild_options": "-sdk appletvos",
+			}),
+			xcodeMajorVersion: 15,
+			want:              Config{},
+			err:               "`-sdk` option found in XcodebuildOptions (`xcodebuild_options`), please set Platform (`platform`) input to `auto` as only one can be set",
+		},
+		{
+			name: "build parallelism should not be negative",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path":      projectPath,
+				"scheme":            "My Scheme",
+				"build_parallelism": "-2",
+			}),
+			want: Config{},
+			err:  "issue with input BuildParallelism: should be a positive number, or 0 to keep xcodebuild's automatic parallelism",
+		},
+		{
+			name: "build parallelism conflicts with -jobs xcodebuild option",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path":       projectPath,
+				"scheme":             "My Scheme",
+				"build_parallelism":  "4",
+				"xcodebuild_options": "-jobs 8",
+			}),
+			want: Config{},
+			err:  "`-jobs` option found in XcodebuildOptions (`xcodebuild_options`), please clear Build parallelism (`build_parallelism`) input as only one can be set",
+		},
+		{
+			name: "max retry count should not be negative",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path":    projectPath,
+				"scheme":          "My Scheme",
+				"max_retry_count": "-1",
+			}),
+			want: Config{},
+			err:  "issue with input MaxRetryCount: should not be negative",
+		},
+		{
+			name: "max retry count limit should be positive",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path":          projectPath,
+				"scheme":                "My Scheme",
+				"max_retry_count_limit": "0",
+			}),
+			want: Config{},
+			err:  "issue with input MaxRetryCountLimit: should be a positive number",
+		},
+		{
+			name: "thinning should be a known value or a device model identifier",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path": projectPath,
+				"scheme":       "My Scheme",
+				"thinning":     "iPhone",
+			}),
+			want: Config{},
+			err:  "issue with input Thinning: invalid value (iPhone), should be none, <thin-for-all-variants> or a device model identifier (eg. iPhone14,2)",
+		},
+		{
+			name: "thinning is not available for app-store exports",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path":        projectPath,
+				"scheme":              "My Scheme",
+				"distribution_method": "app-store",
+				"thinning":            "<thin-for-all-variants>",
+			}),
+			want: Config{},
+			err:  "issue with input Thinning: thinning is not available for app-store exports, it is only supported for ad-hoc, enterprise and development exports",
+		},
+		{
+			name: "manifest is not available for app-store exports",
+			envs: override(thisStepInputs(t), map[string]string{
+				"project_path":        projectPath,
+				"scheme":              "My Scheme",
+				"distribution_method": "app-store",
+				"manifest_app_url":    "https://example.com/Sample.ipa",
+			}),
+			want: Config{},
+			err:  "issue with input Manifest: the manifest is not available for app-store exports, it is only supported for ad-hoc, enterprise and development exports",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			xcodeMajorVersion := tt.xcodeMajorVersion
+			if xcodeMajorVersion == 0 {
+				xcodeMajorVersion = 11
+			}
+
 			envRepository := MockEnvRepository{envs: tt.envs}
 			s := XcodebuildArchiver{
-				xcodeVersionProvider: NewMockXcodeVersionProvider(models.XcodebuildVersionModel{MajorVersion: 11}),
+				xcodeVersionProvider: NewMockXcodeVersionProvider(models.XcodebuildVersionModel{MajorVersion: xcodeMajorVersion}),
 				stepInputParser:      stepconf.NewInputParser(envRepository),
+				pathChecker:          pathutil.NewPathChecker(),
+				pathModifier:         pathutil.NewPathModifier(),
 				logger:               log.NewLogger(),
 			}
 
@@ -45,11 +189,167 @@ func TestXcodeArchiveStep_ProcessInputs(t *testing.T) {
 			gotErr := err != nil
 			wantErr := tt.err != ""
 			require.Equal(t, wantErr, gotErr, fmt.Sprintf("Step.ValidateConfig() error = %v, wantErr %v", err, tt.err))
+			if wantErr {
+				require.ErrorContains(t, err, tt.err)
+			}
 			require.Equal(t, tt.want, config)
 		})
 	}
 }
 
+func TestXcodeArchiveStep_ProcessInputs_projectGeneratedByPreArchiveScript(t *testing.T) {
+	projectPath := filepath.Join(t.TempDir(), "Generated.xcodeproj")
+	envRepository := MockEnvRepository{envs: override(thisStepInputs(t), map[string]string{
+		"project_path":       projectPath,
+		"scheme":             "My Scheme",
+		"pre_archive_script": "tuist generate",
+		"output_dir":         t.TempDir(),
+	})}
+	factory := &recordingCommandFactory{}
+	s := XcodebuildArchiver{
+		xcodeVersionProvider: NewMockXcodeVersionProvider(models.XcodebuildVersionModel{MajorVersion: 15}),
+		stepInputParser:      stepconf.NewInputParser(envRepository),
+		pathProvider:         pathutil.NewPathProvider(),
+		pathChecker:          pathutil.NewPathChecker(),
+		pathModifier:         pathutil.NewPathModifier(),
+		fileManager:          v2fileutil.NewFileManager(),
+		cmdFactory:           factory,
+		logger:               log.NewLogger(),
+	}
+
+	config, err := s.ProcessInputs()
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, os.RemoveAll(config.TempWorkDir)) })
+	require.True(t, config.ProjectGenerated)
+	require.Equal(t, projectPath, config.ProjectPath)
+	require.Equal(t, "My Scheme", config.Scheme)
+	require.Zero(t, factory.count("bash"))
+	require.Zero(t, factory.count("xcodebuild -list"))
+}
+
+func TestXcodeArchiveStep_ProcessInputs_printProjectInfoSkipsSchemeAndExportValidation(t *testing.T) {
+	tempDir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	projectPath := filepath.Join(tempDir, "Sample.xcodeproj")
+	require.NoError(t, os.MkdirAll(projectPath, 0755))
+
+	envRepository := MockEnvRepository{envs: override(thisStepInputs(t), map[string]string{
+		"project_path":                 projectPath,
+		"scheme":                       "Missing",
+		"export_options_plist_content": "not a plist",
+		"print_project_info":           "yes",
+	})}
+	listCmd := "xcodebuild -list -json -project " + projectPath
+	factory := &recordingCommandFactory{outputs: map[string]string{
+		listCmd: `{"project":{"configurations":["Debug","Release"],"name":"Sample","schemes":["Sample"],"targets":["Sample"]}}`,
+	}}
+	s := XcodebuildArchiver{
+		xcodeVersionProvider: NewMockXcodeVersionProvider(models.XcodebuildVersionModel{MajorVersion: 15}),
+		stepInputParser:      stepconf.NewInputParser(envRepository),
+		pathChecker:          pathutil.NewPathChecker(),
+		pathModifier:         pathutil.NewPathModifier(),
+		cmdFactory:           factory,
+		logger:               log.NewLogger(),
+	}
+
+	config, err := s.ProcessInputs()
+	require.NoError(t, err)
+	require.True(t, config.PrintProjectInfo)
+	require.Equal(t, "", config.Scheme)
+
+	require.NoError(t, s.PrintProjectInfo(PrintProjectInfoOpts{
+		ProjectPath:    config.ProjectPath,
+		Scheme:         config.Scheme,
+		XcodebuildPath: config.XcodebuildPath,
+	}))
+	require.Equal(t, []string{listCmd, listCmd}, factory.commands)
+}
+
+func TestXcodeArchiveStep_ProcessInputs_simulatorBuild(t *testing.T) {
+	tests := []struct {
+		name string
+		envs map[string]string
+	}{
+		{
+			name: "export method is not validated against the platform",
+			envs: map[string]string{
+				"platform":            "watchos",
+				"destination":         "generic/platform=watchOS Simulator",
+				"distribution_method": "enterprise",
+			},
+		},
+		{
+			name: "thinning is not validated against the export method",
+			envs: map[string]string{
+				"build_for_simulator": "yes",
+				"distribution_method": "app-store",
+				"thinning":            "<thin-for-all-variants>",
+			},
+		},
+		{
+			name: "thinning is not validated against the export method of a Simulator destination",
+			envs: map[string]string{
+				"destination":         "generic/platform=iOS Simulator",
+				"distribution_method": "app-store",
+				"thinning":            "<thin-for-all-variants>",
+			},
+		},
+		{
+			name: "manifest is not validated against the export method",
+			envs: map[string]string{
+				"build_for_simulator": "yes",
+				"distribution_method": "app-store",
+				"manifest_app_url":    "https://example.com/Sample.ipa",
+			},
+		},
+		{
+			name: "manifest is not validated against the export method of a Simulator destination",
+			envs: map[string]string{
+				"destination":         "generic/platform=iOS Simulator",
+				"distribution_method": "app-store",
+				"manifest_app_url":    "https://example.com/Sample.ipa",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := filepath.EvalSymlinks(t.TempDir())
+			require.NoError(t, err)
+			projectPath := filepath.Join(tempDir, "Sample.xcodeproj")
+			require.NoError(t, os.MkdirAll(filepath.Join(projectPath, "xcshareddata", "xcschemes"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(projectPath, "xcshareddata", "xcschemes", "Sample.xcscheme"), nil, 0644))
+
+			envs := map[string]string{
+				"project_path": projectPath,
+				"scheme":       "Sample",
+				"output_dir":   t.TempDir(),
+			}
+			for key, value := range tt.envs {
+				envs[key] = value
+			}
+			factory := &recordingCommandFactory{outputs: map[string]string{
+				"xcodebuild -list -json -project " + projectPath: `{"project":{"name":"Sample","schemes":["Sample"]}}`,
+			}}
+			s := XcodebuildArchiver{
+				xcodeVersionProvider: NewMockXcodeVersionProvider(models.XcodebuildVersionModel{MajorVersion: 15}),
+				stepInputParser:      stepconf.NewInputParser(MockEnvRepository{envs: override(thisStepInputs(t), envs)}),
+				pathProvider:         pathutil.NewPathProvider(),
+				pathChecker:          pathutil.NewPathChecker(),
+				pathModifier:         pathutil.NewPathModifier(),
+				fileManager:          v2fileutil.NewFileManager(),
+				cmdFactory:           factory,
+				logger:               log.NewLogger(),
+			}
+
+			config, err := s.ProcessInputs()
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, os.RemoveAll(config.TempWorkDir)) })
+			require.True(t, config.BuildForSimulator)
+			require.True(t, config.SkipCodesigning)
+		})
+	}
+}
+
 type MockXcodeVersionProvider struct {
 	version models.XcodebuildVersionModel
 }
@@ -0,0 +1,48 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	bitriseBuildSummaryPthEnvKey = "BITRISE_BUILD_SUMMARY_PATH"
+	buildSummaryFilename         = "build_summary.json"
+)
+
+// BuildSummary is a machine readable summary of the Step run, written to the OutputDir.
+type BuildSummary struct {
+	Phases          []PhaseDuration `json:"phases"`
+	FreeDiskSpaceMB uint64          `json:"free_disk_space_mb"`
+	// Attempts is the number of archive attempts, Flaky is true if the archive succeeded only after a retry
+	Attempts int  `json:"attempts"`
+	Flaky    bool `json:"flaky"`
+
+	Entitlements *EntitlementsSummary `json:"entitlements,omitempty"`
+	AppVersion   *AppVersion          `json:"app_version,omitempty"`
+	Git          *GitInfo             `json:"git,omitempty"`
+
+	ThinnedVariants []ThinnedVariant `json:"thinned_variants,omitempty"`
+	IPAVerification *IPAVerification `json:"ipa_verification,omitempty"`
+}
+
+// ExportBuildSummary writes the build summary into the OutputDir and exports its path.
+func (s XcodebuildArchiver) ExportBuildSummary(outputDir string, overwriteOutputs bool, summary BuildSummary) error {
+	content, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode build summary: %w", err)
+	}
+
+	outputPaths := outputPathResolver{overwrite: overwriteOutputs, logger: s.logger}
+	summaryPath, err := outputPaths.resolve(filepath.Join(outputDir, buildSummaryFilename))
+	if err != nil {
+		return err
+	}
+	if err := ExportOutputFileContent(s.cmdFactory, string(content), summaryPath, bitriseBuildSummaryPthEnvKey); err != nil {
+		return fmt.Errorf("failed to export %s: %w", bitriseBuildSummaryPthEnvKey, err)
+	}
+	s.logger.Donef("The build summary path is now available in the Environment Variable: %s (value: %s)", bitriseBuildSummaryPthEnvKey, summaryPath)
+
+	return nil
+}
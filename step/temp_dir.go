@@ -0,0 +1,56 @@
+package step
+
+import (
+	"fmt"
+	"os"
+
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+)
+
+// newTempDir creates a temp dir inside the Step's temp working directory,
+// or in the OS temp dir if no temp working directory is set.
+func newTempDir(tempWorkDir, prefix string) (string, error) {
+	if tempWorkDir == "" {
+		return v1pathutil.NormalizedOSTempDirPath(prefix)
+	}
+	return os.MkdirTemp(tempWorkDir, prefix)
+}
+
+// CleanupTempWorkDirOpts ...
+type CleanupTempWorkDirOpts struct {
+	TempWorkDir string
+	Cleanup     bool
+	// Preserve keeps the temp working directory even if Cleanup is set, for example to debug a failed archive
+	Preserve bool
+}
+
+// CleanupTempWorkDir removes the Step's temp working directory at the end of the run.
+// The outputs, including the xcarchive and the dSYM dir, are copied into the OutputDir, so they are not affected.
+func (s XcodebuildArchiver) CleanupTempWorkDir(opts CleanupTempWorkDirOpts) {
+	if opts.TempWorkDir == "" {
+		return
+	}
+	if !opts.Cleanup {
+		s.logger.Printf("Keeping the temp working directory: %s", opts.TempWorkDir)
+		return
+	}
+	if opts.Preserve {
+		s.logger.Warnf("The archive failed, keeping the temp working directory: %s", opts.TempWorkDir)
+		return
+	}
+
+	if err := os.RemoveAll(opts.TempWorkDir); err != nil {
+		s.logger.Warnf("Failed to remove the temp working directory (%s): %s", opts.TempWorkDir, err)
+		return
+	}
+	s.logger.Printf("Removed the temp working directory: %s", opts.TempWorkDir)
+}
+
+// createTempWorkDir creates the Step's temp working directory, which holds the archive, the IPA export and the dSYMs of the run.
+func (s XcodebuildArchiver) createTempWorkDir() (string, error) {
+	dir, err := s.pathProvider.CreateTempDir("xcode-archive")
+	if err != nil {
+		return "", fmt.Errorf("failed to create the temp working directory: %w", err)
+	}
+	return dir, nil
+}
@@ -0,0 +1,92 @@
+package step
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/env"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newTempDir(t *testing.T) {
+	tempWorkDir := t.TempDir()
+
+	dir, err := newTempDir(tempWorkDir, "xcodeArchive")
+	require.NoError(t, err)
+	require.Equal(t, tempWorkDir, filepath.Dir(dir))
+	require.True(t, fileExists(t, dir))
+}
+
+func TestCleanupTempWorkDir(t *testing.T) {
+	tests := []struct {
+		name        string
+		cleanup     bool
+		preserve    bool
+		wantRemoved bool
+	}{
+		{name: "cleanup", cleanup: true, wantRemoved: true},
+		{name: "cleanup disabled", cleanup: false, wantRemoved: false},
+		{name: "preserved after a failed archive", cleanup: true, preserve: true, wantRemoved: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempWorkDir := filepath.Join(t.TempDir(), "xcode-archive")
+			require.NoError(t, os.MkdirAll(filepath.Join(tempWorkDir, "xcodeArchive"), 0755))
+
+			archiver := XcodebuildArchiver{logger: log.NewLogger()}
+			archiver.CleanupTempWorkDir(CleanupTempWorkDirOpts{TempWorkDir: tempWorkDir, Cleanup: tt.cleanup, Preserve: tt.preserve})
+
+			require.Equal(t, tt.wantRemoved, !fileExists(t, tempWorkDir))
+		})
+	}
+}
+
+// zippingCommandFactory runs the zip commands, the other commands are only recorded.
+type zippingCommandFactory struct {
+	*recordingCommandFactory
+}
+
+func (f zippingCommandFactory) Create(name string, args []string, opts *command.Opts) command.Command {
+	if name == "/usr/bin/zip" {
+		return command.NewFactory(env.NewRepository()).Create(name, args, opts)
+	}
+	return f.recordingCommandFactory.Create(name, args, opts)
+}
+
+func TestCleanupTempWorkDir_keepsExportedArchive(t *testing.T) {
+	for _, tool := range []string{"rsync", "/usr/bin/zip"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("the outputs are exported with %s", tool)
+		}
+	}
+
+	tempWorkDir := filepath.Join(t.TempDir(), "xcode-archive")
+	archivePath := filepath.Join(tempWorkDir, "xcodeArchive", "MyApp.xcarchive")
+	require.NoError(t, os.MkdirAll(filepath.Join(archivePath, "Products", "Applications", "MyApp.app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(archivePath, "Info.plist"), []byte("archive"), 0644))
+
+	factory := &recordingCommandFactory{}
+	archiver := XcodebuildArchiver{cmdFactory: zippingCommandFactory{factory}, logger: log.NewLogger()}
+	outputDir := t.TempDir()
+	require.NoError(t, archiver.exportUnsignedArchive(archivePath, outputDir, "MyApp", outputPathResolver{logger: log.NewLogger()}))
+
+	archiver.CleanupTempWorkDir(CleanupTempWorkDirOpts{TempWorkDir: tempWorkDir, Cleanup: true})
+	require.False(t, fileExists(t, tempWorkDir))
+
+	exported := map[string]string{}
+	for i, cmd := range factory.commands {
+		value, err := io.ReadAll(factory.opts[i].Stdin)
+		require.NoError(t, err)
+		exported[cmd] = string(value)
+	}
+	require.Equal(t, filepath.Join(outputDir, "MyApp.xcarchive"), exported["envman add --key "+bitriseXCArchivePthEnvKey])
+	for _, pth := range exported {
+		require.True(t, fileExists(t, pth), pth)
+	}
+	require.FileExists(t, filepath.Join(outputDir, "MyApp.xcarchive", "Info.plist"))
+}
@@ -0,0 +1,71 @@
+package step
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+)
+
+// recordingCommandFactory records the created commands, which succeed without running,
+// except for the commands starting with failPrefix (if set). The output of a command is looked up in outputs.
+type recordingCommandFactory struct {
+	commands   []string
+	opts       []*command.Opts
+	failPrefix string
+	outputs    map[string]string
+}
+
+func (f *recordingCommandFactory) Create(name string, args []string, opts *command.Opts) command.Command {
+	cmd := strings.Join(append([]string{name}, args...), " ")
+	f.commands = append(f.commands, cmd)
+	f.opts = append(f.opts, opts)
+
+	var err error
+	if f.failPrefix != "" && strings.HasPrefix(cmd, f.failPrefix) {
+		err = errors.New("exit status 1")
+	}
+	return recordedCommand{cmd: cmd, out: f.outputs[cmd], err: err}
+}
+
+func (f *recordingCommandFactory) count(prefix string) int {
+	count := 0
+	for _, cmd := range f.commands {
+		if strings.HasPrefix(cmd, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+type recordedCommand struct {
+	cmd string
+	out string
+	err error
+}
+
+func (c recordedCommand) PrintableCommandArgs() string                       { return c.cmd }
+func (c recordedCommand) Run() error                                         { return c.err }
+func (c recordedCommand) RunAndReturnExitCode() (int, error)                 { return 0, c.err }
+func (c recordedCommand) RunAndReturnTrimmedOutput() (string, error)         { return c.out, c.err }
+func (c recordedCommand) RunAndReturnTrimmedCombinedOutput() (string, error) { return c.out, c.err }
+func (c recordedCommand) Start() error                                       { return nil }
+func (c recordedCommand) Wait() error                                        { return nil }
+
+// fakePathChecker reports the paths of existing as existing.
+type fakePathChecker struct {
+	existing []string
+}
+
+func (c fakePathChecker) IsPathExists(pth string) (bool, error) {
+	for _, existing := range c.existing {
+		if existing == pth {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c fakePathChecker) IsDirExists(pth string) (bool, error) {
+	return c.IsPathExists(pth)
+}
@@ -0,0 +1,124 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	v1fileutil "github.com/bitrise-io/go-utils/fileutil"
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+)
+
+const (
+	bitriseAppThinningSizeReportPthEnvKey = "BITRISE_APP_THINNING_SIZE_REPORT_PATH"
+	appThinningSizeReportFilename         = "App Thinning Size Report.txt"
+
+	thinningForAllVariants = "<thin-for-all-variants>"
+)
+
+var deviceModelIdentifierRegexp = regexp.MustCompile(`^[A-Za-z]+[0-9]+,[0-9]+$`)
+
+// ThinnedVariant is an app variant listed in the App Thinning Size Report.
+type ThinnedVariant struct {
+	Name             string `json:"name"`
+	CompressedSize   string `json:"compressed_size"`
+	UncompressedSize string `json:"uncompressed_size"`
+}
+
+// parseThinning validates the Thinning input and returns the value of the export options' thinning key:
+// none, <thin-for-all-variants> or a device model identifier (eg. iPhone14,2).
+func parseThinning(thinning string) (string, error) {
+	switch strings.TrimSpace(thinning) {
+	case "", exportoptions.ThinningNone, "<none>":
+		return exportoptions.ThinningNone, nil
+	case exportoptions.ThinningThinForAllVariants, thinningForAllVariants:
+		return thinningForAllVariants, nil
+	}
+
+	thinning = strings.TrimSpace(thinning)
+	if !deviceModelIdentifierRegexp.MatchString(thinning) {
+		return "", fmt.Errorf("invalid value (%s), should be none, %s or a device model identifier (eg. iPhone14,2)", thinning, thinningForAllVariants)
+	}
+	return thinning, nil
+}
+
+// validateThinning checks that the export method supports thinning, App Store exports are thinned by the App Store itself.
+func validateThinning(thinning, exportMethod string) error {
+	if thinning == exportoptions.ThinningNone {
+		return nil
+	}
+	if exportMethod == string(exportoptions.MethodAppStore) {
+		return fmt.Errorf("thinning is not available for %s exports, it is only supported for ad-hoc, enterprise and development exports", exportMethod)
+	}
+	return nil
+}
+
+// applyThinning sets the thinning key of the generated export options; App Store export options are left untouched.
+func applyThinning(exportOpts exportoptions.ExportOptions, thinning string) exportoptions.ExportOptions {
+	if options, ok := exportOpts.(exportoptions.NonAppStoreOptionsModel); ok {
+		options.Thinning = thinning
+		return options
+	}
+	return exportOpts
+}
+
+// parseAppThinningSizeReport parses the variants and their app sizes from the App Thinning Size Report.
+func parseAppThinningSizeReport(content string) []ThinnedVariant {
+	var variants []ThinnedVariant
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+
+		if name := strings.TrimPrefix(line, "Variant:"); name != line {
+			variants = append(variants, ThinnedVariant{Name: strings.TrimSpace(name)})
+			continue
+		}
+
+		sizes := strings.TrimPrefix(line, "App size:")
+		if sizes == line || len(variants) == 0 {
+			continue
+		}
+
+		variant := &variants[len(variants)-1]
+		for _, size := range strings.Split(sizes, ",") {
+			size = strings.TrimSpace(size)
+			if value := strings.TrimSuffix(size, " uncompressed"); value != size {
+				variant.UncompressedSize = value
+			} else if value := strings.TrimSuffix(size, " compressed"); value != size {
+				variant.CompressedSize = value
+			}
+		}
+	}
+	return variants
+}
+
+// exportAppThinningSizeReport exports the App Thinning Size Report of a thinned export and returns the listed variants.
+func (s XcodebuildArchiver) exportAppThinningSizeReport(ipaExportDir, outputDir string, outputPaths outputPathResolver) ([]ThinnedVariant, error) {
+	reportPath := filepath.Join(ipaExportDir, appThinningSizeReportFilename)
+	if exist, err := v1pathutil.IsPathExists(reportPath); err != nil {
+		return nil, fmt.Errorf("failed to check if %s exists: %w", reportPath, err)
+	} else if !exist {
+		return nil, nil
+	}
+
+	content, err := v1fileutil.ReadStringFromFile(reportPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", reportPath, err)
+	}
+
+	deployPath, err := outputPaths.resolve(filepath.Join(outputDir, appThinningSizeReportFilename))
+	if err != nil {
+		return nil, err
+	}
+	if err := ExportOutputFile(s.cmdFactory, reportPath, deployPath, bitriseAppThinningSizeReportPthEnvKey); err != nil {
+		return nil, fmt.Errorf("failed to export %s: %w", bitriseAppThinningSizeReportPthEnvKey, err)
+	}
+	s.logger.Donef("The App Thinning Size Report path is now available in the Environment Variable: %s (value: %s)", bitriseAppThinningSizeReportPthEnvKey, deployPath)
+
+	variants := parseAppThinningSizeReport(content)
+	for _, variant := range variants {
+		s.logger.Printf("- %s: %s compressed, %s uncompressed", variant.Name, variant.CompressedSize, variant.UncompressedSize)
+	}
+	return variants, nil
+}
@@ -0,0 +1,72 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseThinning(t *testing.T) {
+	tests := []struct {
+		name     string
+		thinning string
+		want     string
+		wantErr  bool
+	}{
+		{name: "empty", thinning: "", want: exportoptions.ThinningNone},
+		{name: "none", thinning: "none", want: exportoptions.ThinningNone},
+		{name: "all variants", thinning: "<thin-for-all-variants>", want: "<thin-for-all-variants>"},
+		{name: "all variants without brackets", thinning: "thin-for-all-variants", want: "<thin-for-all-variants>"},
+		{name: "device model identifier", thinning: "iPhone14,2", want: "iPhone14,2"},
+		{name: "invalid", thinning: "iPhone 13", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseThinning(tt.thinning)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_validateThinning(t *testing.T) {
+	require.NoError(t, validateThinning(exportoptions.ThinningNone, "app-store"))
+	require.NoError(t, validateThinning("<thin-for-all-variants>", "ad-hoc"))
+	require.Error(t, validateThinning("iPhone14,2", "app-store"))
+}
+
+func Test_applyThinning(t *testing.T) {
+	got := applyThinning(exportoptions.NewNonAppStoreOptions(exportoptions.MethodAdHoc), "iPhone14,2")
+	require.Equal(t, "iPhone14,2", got.Hash()[exportoptions.ThinningKey])
+
+	appStoreOptions := exportoptions.NewAppStoreOptions()
+	require.Equal(t, appStoreOptions, applyThinning(appStoreOptions, "iPhone14,2"))
+}
+
+func Test_parseAppThinningSizeReport(t *testing.T) {
+	report := `App Thinning Size Report for All Variants of Sample
+
+Variant: Sample.ipa
+Supported variant descriptors: Universal
+App + On Demand Resources size: 8.9 MB compressed, 25.1 MB uncompressed
+App size: 8.9 MB compressed, 25.1 MB uncompressed
+On Demand Resources size: Zero KB compressed, Zero KB uncompressed
+
+
+Variant: Sample-1B2C3D.ipa
+Supported variant descriptors: [device: iPhone14,2, os-version: 16.0]
+App + On Demand Resources size: 5.2 MB compressed, 14.3 MB uncompressed
+App size: 5.2 MB compressed, 14.3 MB uncompressed
+On Demand Resources size: Zero KB compressed, Zero KB uncompressed
+`
+	require.Equal(t, []ThinnedVariant{
+		{Name: "Sample.ipa", CompressedSize: "8.9 MB", UncompressedSize: "25.1 MB"},
+		{Name: "Sample-1B2C3D.ipa", CompressedSize: "5.2 MB", UncompressedSize: "14.3 MB"},
+	}, parseAppThinningSizeReport(report))
+	require.Nil(t, parseAppThinningSizeReport(""))
+}
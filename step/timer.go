@@ -0,0 +1,66 @@
+package step
+
+import (
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+// PhaseDuration is the measured duration of a Step phase.
+type PhaseDuration struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"duration_seconds"`
+}
+
+// Timer measures the duration of the Step's phases, in the order they were started.
+type Timer struct {
+	now    func() time.Time
+	since  func(time.Time) time.Duration
+	phases []PhaseDuration
+}
+
+// NewTimer ...
+func NewTimer() *Timer {
+	return &Timer{
+		now:   time.Now,
+		since: time.Since,
+	}
+}
+
+// Start starts measuring the given phase, the returned function stops the measurement.
+func (t *Timer) Start(phase string) func() {
+	start := t.now()
+	index := len(t.phases)
+	t.phases = append(t.phases, PhaseDuration{Name: phase})
+
+	return func() {
+		duration := t.since(start)
+		t.phases[index].Duration = duration
+		t.phases[index].Seconds = duration.Seconds()
+	}
+}
+
+// Phases returns the measured phases.
+func (t *Timer) Phases() []PhaseDuration {
+	return t.phases
+}
+
+// Total returns the sum of the measured phase durations.
+func (t *Timer) Total() time.Duration {
+	var total time.Duration
+	for _, phase := range t.phases {
+		total += phase.Duration
+	}
+	return total
+}
+
+// PrintPhases logs the phase breakdown.
+func (t *Timer) PrintPhases(logger log.Logger) {
+	logger.Println()
+	logger.Infof("Step phase durations:")
+	for _, phase := range t.phases {
+		logger.Printf("- %s: %s", phase.Name, phase.Duration.Round(time.Millisecond))
+	}
+	logger.Printf("Total: %s", t.Total().Round(time.Millisecond))
+}
@@ -0,0 +1,33 @@
+package step
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	elapsed := []time.Duration{2 * time.Second, 90 * time.Second}
+
+	timer := &Timer{
+		now: func() time.Time { return start },
+		since: func(time.Time) time.Duration {
+			d := elapsed[0]
+			elapsed = elapsed[1:]
+			return d
+		},
+	}
+
+	stopInputs := timer.Start("process_inputs")
+	stopArchive := timer.Start("archive_attempt_1")
+	stopInputs()
+	stopArchive()
+
+	require.Equal(t, []PhaseDuration{
+		{Name: "process_inputs", Duration: 2 * time.Second, Seconds: 2},
+		{Name: "archive_attempt_1", Duration: 90 * time.Second, Seconds: 90},
+	}, timer.Phases())
+	require.Equal(t, 92*time.Second, timer.Total())
+}
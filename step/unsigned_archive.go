@@ -0,0 +1,49 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// exportUnsignedArchive exports the archive created with code signing skipped. The unsigned archive can not be parsed
+// as an iOS archive (its app has no embedded provisioning profile), so only the archive and its app are exported.
+func (s XcodebuildArchiver) exportUnsignedArchive(archivePath, outputDir, artifactName string, outputPaths outputPathResolver) error {
+	// the archive is created in the temp working directory, which might be removed at the end of the run
+	archiveOutputPath, err := outputPaths.resolve(filepath.Join(outputDir, artifactName+".xcarchive"))
+	if err != nil {
+		return err
+	}
+	if err := ExportOutputDir(s.cmdFactory, archivePath, archiveOutputPath, bitriseXCArchivePthEnvKey, s.logger); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", bitriseXCArchivePthEnvKey, err)
+	}
+	s.logger.Donef("The unsigned xcarchive path is now available in the Environment Variable: %s (value: %s)", bitriseXCArchivePthEnvKey, archiveOutputPath)
+
+	archiveZipPath, err := outputPaths.resolve(filepath.Join(outputDir, artifactName+".xcarchive.zip"))
+	if err != nil {
+		return err
+	}
+	if err := ExportOutputDirAsZip(s.cmdFactory, archivePath, archiveZipPath, bitriseXCArchiveZipPthEnvKey, s.logger); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", bitriseXCArchiveZipPthEnvKey, err)
+	}
+	s.logger.Donef("The unsigned xcarchive zip path is now available in the Environment Variable: %s (value: %s)", bitriseXCArchiveZipPthEnvKey, archiveZipPath)
+
+	appPaths, err := filepath.Glob(filepath.Join(archivePath, "Products", "Applications", "*.app"))
+	if err != nil {
+		return fmt.Errorf("failed to search for the app in the archive: %w", err)
+	}
+	if len(appPaths) == 0 {
+		s.logger.Warnf("No app found in the unsigned archive")
+		return nil
+	}
+
+	appPath, err := outputPaths.resolve(filepath.Join(outputDir, artifactName+".app"))
+	if err != nil {
+		return err
+	}
+	if err := ExportOutputDir(s.cmdFactory, appPaths[0], appPath, bitriseAppDirPthEnvKey, s.logger); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", bitriseAppDirPthEnvKey, err)
+	}
+	s.logger.Donef("The unsigned app directory is now available in the Environment Variable: %s (value: %s)", bitriseAppDirPthEnvKey, appPath)
+
+	return nil
+}
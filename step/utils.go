@@ -4,11 +4,13 @@ import (
 	"bufio"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/bitrise-io/go-utils/sliceutil"
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/xcodebuild"
 )
 
 func generateAdditionalOptions(platform string, customOptions []string) []string {
@@ -29,6 +31,107 @@ func generateAdditionalOptions(platform string, customOptions []string) []string
 	return options
 }
 
+var knownDestinations = []string{
+	"generic/platform=iOS",
+	"generic/platform=iOS Simulator",
+	"generic/platform=tvOS",
+	"generic/platform=tvOS Simulator",
+	"generic/platform=watchOS",
+	"generic/platform=watchOS Simulator",
+	"generic/platform=visionOS",
+	"generic/platform=visionOS Simulator",
+	"generic/platform=macOS",
+	"generic/platform=macOS,variant=Mac Catalyst",
+	"generic/platform=OS X",
+}
+
+func validateDestination(destination string) error {
+	if sliceutil.IsStringInSlice(destination, knownDestinations) {
+		return nil
+	}
+	return fmt.Errorf("unknown destination (%s), available destinations: %s", destination, strings.Join(knownDestinations, ", "))
+}
+
+// allowProvisioningUpdatesArgs returns the -allowProvisioningUpdates flag if it needs to be passed explicitly.
+// When App Store Connect API authentication params are set, xcodebuild.AuthenticationParams already includes the flag.
+func allowProvisioningUpdatesArgs(allow bool, authOptions *xcodebuild.AuthenticationParams) []string {
+	if !allow || authOptions != nil {
+		return nil
+	}
+	return []string{"-allowProvisioningUpdates"}
+}
+
+// buildParallelismArgs returns the xcodebuild flags of the given build parallelism, 0 keeps xcodebuild's automatic behavior.
+func buildParallelismArgs(jobs int) []string {
+	if jobs <= 0 {
+		return nil
+	}
+	args := []string{"-jobs", strconv.Itoa(jobs)}
+	if jobs > 1 {
+		args = append(args, "-parallelizeTargets")
+	}
+	return args
+}
+
+const (
+	xcodebuildVerbosityDefault = "default"
+	xcodebuildVerbosityQuiet   = "quiet"
+	xcodebuildVerbosityVerbose = "verbose"
+)
+
+// xcodebuildVerbosityArgs returns the xcodebuild flag of the given verbosity.
+// -quiet is only passed with the xcodebuild log formatter: xcpretty already condenses the output,
+// and it could not format the compile steps omitted by -quiet.
+func xcodebuildVerbosityArgs(verbosity, logFormatter string) []string {
+	switch verbosity {
+	case xcodebuildVerbosityQuiet:
+		if logFormatter == "xcpretty" {
+			return nil
+		}
+		return []string{"-quiet"}
+	case xcodebuildVerbosityVerbose:
+		return []string{"-verbose"}
+	default:
+		return nil
+	}
+}
+
+const (
+	minXcodeMajorVersionForSkipPackagePluginValidation = 14
+	minXcodeMajorVersionForSkipMacroValidation         = 15
+)
+
+// packageValidationArgs returns the flags skipping the validation prompts of Swift package plugins and macros,
+// which would otherwise hang the build on CI. Flags unsupported by the Xcode version are left out.
+func packageValidationArgs(skipPackagePluginValidation, skipMacroValidation bool, xcodeMajorVersion int, logger log.Logger) []string {
+	var args []string
+	if skipPackagePluginValidation {
+		if xcodeMajorVersion >= minXcodeMajorVersionForSkipPackagePluginValidation {
+			logger.Printf("Skipping Swift package plugin validation (-skipPackagePluginValidation)")
+			args = append(args, "-skipPackagePluginValidation")
+		} else {
+			logger.Warnf("Swift package plugin validation can only be skipped with Xcode %d or later, current Xcode major version: %d", minXcodeMajorVersionForSkipPackagePluginValidation, xcodeMajorVersion)
+		}
+	}
+	if skipMacroValidation {
+		if xcodeMajorVersion >= minXcodeMajorVersionForSkipMacroValidation {
+			logger.Printf("Skipping Swift macro validation (-skipMacroValidation)")
+			args = append(args, "-skipMacroValidation")
+		} else {
+			logger.Warnf("Swift macro validation can only be skipped with Xcode %d or later, current Xcode major version: %d", minXcodeMajorVersionForSkipMacroValidation, xcodeMajorVersion)
+		}
+	}
+	return args
+}
+
+func logBuildParallelism(jobs int, logger log.Logger) {
+	if jobs <= 0 {
+		logger.Printf("Build parallelism: automatic")
+		return
+	}
+	logger.Printf("Build parallelism: %d jobs", jobs)
+}
+
 func determineExportMethod(desiredExportMethod string, archiveExportMethod exportoptions.Method, logger log.Logger) (exportoptions.Method, error) {
 	if desiredExportMethod == "auto-detect" {
 		logger.Printf("auto-detect export method specified: using the archive profile's export method: %s", archiveExportMethod)
@@ -67,3 +170,73 @@ func findIDEDistrubutionLogsPath(output string, logger log.Logger) (string, erro
 
 	return "", nil
 }
+
+// EnvVar is an additional environment variable passed to the xcodebuild commands.
+type EnvVar struct {
+	Key    string
+	Value  string
+	Secret bool
+}
+
+// parseEnvVars parses newline separated KEY=VALUE environment variables.
+// Invalid lines are not included in the error, as they might contain secret values.
+func parseEnvVars(content string, secret bool) ([]EnvVar, error) {
+	var envVars []EnvVar
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid environment variable in line %d, should be in KEY=VALUE format", i+1)
+		}
+
+		envVars = append(envVars, EnvVar{Key: key, Value: value, Secret: secret})
+	}
+	return envVars, nil
+}
+
+func envVarsToList(envVars []EnvVar) []string {
+	var envs []string
+	for _, envVar := range envVars {
+		envs = append(envs, envVar.Key+"="+envVar.Value)
+	}
+	return envs
+}
+
+// ignoredExportInputs returns the IPA export inputs set to a non-default value, which are ignored
+// when a custom ExportOptions plist content is provided.
+func ignoredExportInputs(inputs Inputs) []string {
+	var ignored []string
+	if inputs.ExportMethod != "development" {
+		ignored = append(ignored, fmt.Sprintf("DistributionMethod (`distribution_method`): %s", inputs.ExportMethod))
+	}
+	if !inputs.UploadBitcode {
+		ignored = append(ignored, "UploadBitcode (`upload_bitcode`): no")
+	}
+	if !inputs.CompileBitcode {
+		ignored = append(ignored, "CompileBitcode (`compile_bitcode`): no")
+	}
+	if inputs.ExportDevelopmentTeam != "" {
+		ignored = append(ignored, fmt.Sprintf("ExportDevelopmentTeam (`export_development_team`): %s", inputs.ExportDevelopmentTeam))
+	}
+	if inputs.ICloudContainerEnvironment != "" {
+		ignored = append(ignored, fmt.Sprintf("ICloudContainerEnvironment (`icloud_container_environment`): %s", inputs.ICloudContainerEnvironment))
+	}
+	if inputs.Thinning != "" && inputs.Thinning != exportoptions.ThinningNone {
+		ignored = append(ignored, fmt.Sprintf("Thinning (`thinning`): %s", inputs.Thinning))
+	}
+	if inputs.ManifestAppURL != "" {
+		ignored = append(ignored, fmt.Sprintf("ManifestAppURL (`manifest_app_url`): %s", inputs.ManifestAppURL))
+	}
+	if inputs.ManifestDisplayImageURL != "" {
+		ignored = append(ignored, fmt.Sprintf("ManifestDisplayImageURL (`manifest_display_image_url`): %s", inputs.ManifestDisplayImageURL))
+	}
+	if inputs.ManifestFullSizeImageURL != "" {
+		ignored = append(ignored, fmt.Sprintf("ManifestFullSizeImageURL (`manifest_full_size_image_url`): %s", inputs.ManifestFullSizeImageURL))
+	}
+	return ignored
+}
@@ -3,6 +3,8 @@ package step
 import (
 	"testing"
 
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/xcodebuild"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,3 +41,152 @@ func Test_generateAdditionalOptions(t *testing.T) {
 		})
 	}
 }
+
+func Test_validateDestination(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		wantErr     bool
+	}{
+		{name: "iOS", destination: "generic/platform=iOS"},
+		{name: "watchOS", destination: "generic/platform=watchOS"},
+		{name: "visionOS", destination: "generic/platform=visionOS"},
+		{name: "macOS", destination: "generic/platform=macOS"},
+		{name: "Mac Catalyst", destination: "generic/platform=macOS,variant=Mac Catalyst"},
+		{name: "typo in platform", destination: "generic/platform=iPhoneOS", wantErr: true},
+		{name: "missing generic prefix", destination: "platform=iOS", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDestination(tt.destination)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_allowProvisioningUpdatesArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		allow       bool
+		authOptions *xcodebuild.AuthenticationParams
+		want        []string
+	}{
+		{name: "not allowed", allow: false, want: nil},
+		{name: "allowed without API key", allow: true, want: []string{"-allowProvisioningUpdates"}},
+		{name: "allowed with API key", allow: true, authOptions: &xcodebuild.AuthenticationParams{KeyID: "id"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allowProvisioningUpdatesArgs(tt.allow, tt.authOptions)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_buildParallelismArgs(t *testing.T) {
+	require.Nil(t, buildParallelismArgs(0))
+	require.Equal(t, []string{"-jobs", "1"}, buildParallelismArgs(1))
+	require.Equal(t, []string{"-jobs", "8", "-parallelizeTargets"}, buildParallelismArgs(8))
+}
+
+func Test_xcodebuildVerbosityArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		verbosity    string
+		logFormatter string
+		want         []string
+	}{
+		{name: "default with xcodebuild", verbosity: "default", logFormatter: "xcodebuild", want: nil},
+		{name: "default with xcpretty", verbosity: "default", logFormatter: "xcpretty", want: nil},
+		{name: "quiet with xcodebuild", verbosity: "quiet", logFormatter: "xcodebuild", want: []string{"-quiet"}},
+		{name: "quiet is not passed with xcpretty", verbosity: "quiet", logFormatter: "xcpretty", want: nil},
+		{name: "verbose with xcodebuild", verbosity: "verbose", logFormatter: "xcodebuild", want: []string{"-verbose"}},
+		{name: "verbose with xcpretty", verbosity: "verbose", logFormatter: "xcpretty", want: []string{"-verbose"}},
+		{name: "empty", verbosity: "", logFormatter: "xcodebuild", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := xcodebuildVerbosityArgs(tt.verbosity, tt.logFormatter)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_packageValidationArgs(t *testing.T) {
+	tests := []struct {
+		name              string
+		skipPlugin        bool
+		skipMacro         bool
+		xcodeMajorVersion int
+		want              []string
+	}{
+		{name: "disabled", xcodeMajorVersion: 15, want: nil},
+		{name: "both on Xcode 15", skipPlugin: true, skipMacro: true, xcodeMajorVersion: 15, want: []string{"-skipPackagePluginValidation", "-skipMacroValidation"}},
+		{name: "macro validation is unsupported on Xcode 14", skipPlugin: true, skipMacro: true, xcodeMajorVersion: 14, want: []string{"-skipPackagePluginValidation"}},
+		{name: "both unsupported on Xcode 13", skipPlugin: true, skipMacro: true, xcodeMajorVersion: 13, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := packageValidationArgs(tt.skipPlugin, tt.skipMacro, tt.xcodeMajorVersion, log.NewLogger())
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseEnvVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		secret  bool
+		want    []EnvVar
+		wantErr bool
+	}{
+		{name: "empty", content: "", want: nil},
+		{
+			name:    "multiple env vars",
+			content: "FEATURE_FLAG=on\n\nBACKEND_URL=https://api.example.com/?a=b\n",
+			want: []EnvVar{
+				{Key: "FEATURE_FLAG", Value: "on"},
+				{Key: "BACKEND_URL", Value: "https://api.example.com/?a=b"},
+			},
+		},
+		{name: "empty value", content: "EMPTY=", want: []EnvVar{{Key: "EMPTY", Value: ""}}},
+		{name: "secret", content: "TOKEN=secret", secret: true, want: []EnvVar{{Key: "TOKEN", Value: "secret", Secret: true}}},
+		{name: "missing separator", content: "FEATURE_FLAG=on\nBACKEND_URL", wantErr: true},
+		{name: "missing key", content: "=value", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnvVars(tt.content, tt.secret)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ignoredExportInputs(t *testing.T) {
+	defaults := Inputs{ExportMethod: "development", UploadBitcode: true, CompileBitcode: true}
+	require.Empty(t, ignoredExportInputs(defaults))
+
+	inputs := Inputs{
+		ExportMethod:               "app-store",
+		UploadBitcode:              false,
+		CompileBitcode:             true,
+		ICloudContainerEnvironment: "Production",
+		Thinning:                   "<thin-for-all-variants>",
+	}
+	require.Equal(t, []string{
+		"DistributionMethod (`distribution_method`): app-store",
+		"UploadBitcode (`upload_bitcode`): no",
+		"ICloudContainerEnvironment (`icloud_container_environment`): Production",
+		"Thinning (`thinning`): <thin-for-all-variants>",
+	}, ignoredExportInputs(inputs))
+}
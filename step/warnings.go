@@ -0,0 +1,41 @@
+package step
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+const buildWarningCountEnvKey = "BITRISE_BUILD_WARNING_COUNT"
+
+// findXcodebuildWarnings returns the distinct warning lines of an xcodebuild log.
+// The same warning is reported once per target (and once per architecture) compiling the source file,
+// so the lines are deduplicated.
+func findXcodebuildWarnings(out string) []string {
+	var warnings []string
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "warning: ") && !strings.Contains(line, " warning: ") {
+			continue
+		}
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		warnings = append(warnings, line)
+	}
+
+	return warnings
+}
+
+// CheckWarningBudget fails if the number of warnings exceeds the allowed maximum. A negative maximum disables the check.
+func CheckWarningBudget(warnings []string, maxAllowed int) error {
+	if maxAllowed < 0 || len(warnings) <= maxAllowed {
+		return nil
+	}
+	return fmt.Errorf("the archive produced %d warnings, which exceeds the allowed maximum (%d):\n%s", len(warnings), maxAllowed, strings.Join(warnings, "\n"))
+}
@@ -0,0 +1,53 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findXcodebuildWarnings(t *testing.T) {
+	log := `CompileSwift normal arm64 /src/App/View.swift (in target 'App' from project 'App')
+/src/Shared/Model.swift:12:9: warning: variable 'x' was never mutated; consider changing to 'let' constant
+/src/Shared/Model.swift:20:5: warning: 'foo()' is deprecated
+CompileSwift normal arm64 /src/Shared/Model.swift (in target 'Widget' from project 'App')
+    /src/Shared/Model.swift:12:9: warning: variable 'x' was never mutated; consider changing to 'let' constant
+ld: warning: ignoring duplicate libraries: '-lc++'
+warning: Run script build phase 'Lint' will be run during every build
+/src/App/View.swift:3:1: error: cannot find 'Foo' in scope
+** ARCHIVE SUCCEEDED **`
+
+	require.Equal(t, []string{
+		"/src/Shared/Model.swift:12:9: warning: variable 'x' was never mutated; consider changing to 'let' constant",
+		"/src/Shared/Model.swift:20:5: warning: 'foo()' is deprecated",
+		"ld: warning: ignoring duplicate libraries: '-lc++'",
+		"warning: Run script build phase 'Lint' will be run during every build",
+	}, findXcodebuildWarnings(log))
+
+	require.Empty(t, findXcodebuildWarnings("** ARCHIVE SUCCEEDED **"))
+}
+
+func TestCheckWarningBudget(t *testing.T) {
+	warnings := []string{"a.swift:1:1: warning: first", "b.swift:1:1: warning: second"}
+
+	tests := []struct {
+		name       string
+		maxAllowed int
+		wantErr    bool
+	}{
+		{name: "disabled", maxAllowed: -1},
+		{name: "within budget", maxAllowed: 2},
+		{name: "exceeds budget", maxAllowed: 1, wantErr: true},
+		{name: "no warnings allowed", maxAllowed: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckWarningBudget(warnings, tt.maxAllowed)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
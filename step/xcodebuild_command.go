@@ -0,0 +1,118 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	v1command "github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/errorutil"
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/xcodebuild"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+)
+
+const defaultXcodebuildPath = "xcodebuild"
+
+// xcodebuildCommand wraps an xcodebuild command model to pass the xcodebuild binary, arguments and environment variables,
+// which are not supported by the model itself.
+type xcodebuildCommand struct {
+	model          xcodebuild.CommandModel
+	xcodebuildPath string
+	additionalArgs []string
+	additionalEnvs []string
+}
+
+func newXcodebuildCommand(model xcodebuild.CommandModel, xcodebuildPath string, additionalArgs []string, additionalEnvs []string) xcodebuildCommand {
+	return xcodebuildCommand{
+		model:          model,
+		xcodebuildPath: xcodebuildPath,
+		additionalArgs: additionalArgs,
+		additionalEnvs: additionalEnvs,
+	}
+}
+
+// Command ...
+func (c xcodebuildCommand) Command() *v1command.Model {
+	// the model's command runs the default xcodebuild, so only its arguments and IO settings are used
+	modelCmd := c.model.Command().GetCmd()
+	args := append(append([]string{}, modelCmd.Args[1:]...), c.additionalArgs...)
+
+	execCmd := exec.Command(xcodebuildPathOrDefault(c.xcodebuildPath), args...)
+	execCmd.Dir = modelCmd.Dir
+	execCmd.Env = modelCmd.Env
+	execCmd.Stdin = modelCmd.Stdin
+	execCmd.Stdout = modelCmd.Stdout
+	execCmd.Stderr = modelCmd.Stderr
+
+	cmd := v1command.NewWithCmd(execCmd)
+	if len(c.additionalEnvs) > 0 {
+		cmd.AppendEnvs(c.additionalEnvs...)
+	}
+	return cmd
+}
+
+// PrintableCmd ...
+func (c xcodebuildCommand) PrintableCmd() string {
+	return v1command.PrintableCommandArgs(false, c.Command().GetCmd().Args)
+}
+
+func xcodebuildPathOrDefault(xcodebuildPath string) string {
+	if xcodebuildPath == "" {
+		return defaultXcodebuildPath
+	}
+	return xcodebuildPath
+}
+
+// runShowBuildSettings runs the show build settings command model with the given xcodebuild binary,
+// and returns the build settings of its targets (in case of multiple targets, the later targets override the earlier ones).
+func runShowBuildSettings(cmdFactory command.Factory, logger log.Logger, xcodebuildPath string, model *xcodebuild.ShowBuildSettingsCommandModel) (serialized.Object, error) {
+	args := append(append([]string{}, model.Command().GetCmd().Args[1:]...), "-json")
+	cmd := cmdFactory.Create(xcodebuildPathOrDefault(xcodebuildPath), args, nil)
+
+	logger.TPrintf("Reading build settings...")
+	logger.TDonef("$ %s", cmd.PrintableCommandArgs())
+	out, err := cmd.RunAndReturnTrimmedOutput()
+	if err != nil {
+		if errorutil.IsExitStatusError(err) {
+			return nil, fmt.Errorf("%s command failed, output: %s", cmd.PrintableCommandArgs(), out)
+		}
+		return nil, fmt.Errorf("failed to run command %s: %s", cmd.PrintableCommandArgs(), err)
+	}
+
+	targets, err := parseShowBuildSettings(out)
+	if err != nil {
+		return nil, err
+	}
+	settings := serialized.Object{}
+	for _, target := range targets {
+		for key, value := range target.BuildSettings {
+			settings[key] = value
+		}
+	}
+	return settings, nil
+}
+
+// resolveXcodebuildPath checks that the xcodebuild binary exists and is executable,
+// and returns its path, a command name (without a path separator) is looked up in the PATH.
+func resolveXcodebuildPath(xcodebuildPath string) (string, error) {
+	if !strings.Contains(xcodebuildPath, string(filepath.Separator)) {
+		pth, err := exec.LookPath(xcodebuildPath)
+		if err != nil {
+			return "", fmt.Errorf("%s not found in PATH: %w", xcodebuildPath, err)
+		}
+		return pth, nil
+	}
+
+	info, err := os.Stat(xcodebuildPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to check %s: %w", xcodebuildPath, err)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return "", fmt.Errorf("%s is not an executable file", xcodebuildPath)
+	}
+	return xcodebuildPath, nil
+}
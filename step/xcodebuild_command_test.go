@@ -0,0 +1,77 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/xcodebuild"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_xcodebuildCommand_Command(t *testing.T) {
+	model := xcodebuild.NewCommandBuilder("Sample.xcodeproj", "archive")
+
+	args := newXcodebuildCommand(model, "", []string{"-allowProvisioningUpdates"}, nil).Command().GetCmd().Args
+	require.Equal(t, "xcodebuild", args[0])
+	require.Equal(t, "-allowProvisioningUpdates", args[len(args)-1])
+
+	cmd := newXcodebuildCommand(model, "/opt/tools/xcodebuild-wrapper", nil, nil).Command().GetCmd()
+	require.Equal(t, "/opt/tools/xcodebuild-wrapper", cmd.Path)
+	require.Equal(t, "/opt/tools/xcodebuild-wrapper", cmd.Args[0])
+	require.NoError(t, cmd.Err)
+}
+
+func Test_resolveXcodebuildPath(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "xcodebuild-wrapper")
+	require.NoError(t, os.WriteFile(executable, []byte("#!/bin/sh\n"), 0755))
+	nonExecutable := filepath.Join(dir, "xcodebuild-plain")
+	require.NoError(t, os.WriteFile(nonExecutable, []byte("#!/bin/sh\n"), 0644))
+
+	binDir := t.TempDir()
+	inPath := filepath.Join(binDir, "xcodebuild-in-path")
+	require.NoError(t, os.WriteFile(inPath, []byte("#!/bin/sh\n"), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "executable file", path: executable, want: executable},
+		{name: "command in PATH", path: "xcodebuild-in-path", want: inPath},
+		{name: "missing file", path: filepath.Join(dir, "missing"), wantErr: true},
+		{name: "not executable", path: nonExecutable, wantErr: true},
+		{name: "directory", path: dir, wantErr: true},
+		{name: "missing command", path: "xcodebuild-does-not-exist", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveXcodebuildPath(tt.path)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestXcodeBuild_TargetBuildSettings(t *testing.T) {
+	cmd := "/opt/tools/xcodebuild-wrapper -project /project/Sample.xcodeproj -target Sample -configuration Release -showBuildSettings -json"
+	factory := &recordingCommandFactory{outputs: map[string]string{
+		cmd: `[{"action":"build","buildSettings":{"SDKROOT":"iphoneos","PRODUCT_NAME":"Sample"},"target":"Sample"}]`,
+	}}
+	provider := XcodeBuild{XcodebuildPath: "/opt/tools/xcodebuild-wrapper", CmdFactory: factory, Logger: log.NewLogger()}
+
+	settings, err := provider.TargetBuildSettings(&xcodeproj.XcodeProj{Path: "/project/Sample.xcodeproj"}, "Sample", "Release")
+	require.NoError(t, err)
+	require.Equal(t, []string{cmd}, factory.commands)
+	require.Equal(t, serialized.Object{"SDKROOT": "iphoneos", "PRODUCT_NAME": "Sample"}, settings)
+}